@@ -1,10 +1,16 @@
 package lib
 
 import (
+	"errors"
+	"math"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
+// ErrOutOfRange is returned when an amount doesn't fit in int64 minor units
+var ErrOutOfRange = errors.New("amount out of range")
+
 // DecimalsNumber returns the number of decimals in a float
 func DecimalsNumber(f float64) int {
 	s := strconv.FormatFloat(f, 'f', -1, 64)
@@ -15,16 +21,175 @@ func DecimalsNumber(f float64) int {
 	return len(p[1])
 }
 
-// ToCents returns the cents representation in int64
+// ToCents returns the cents representation in int64, rounded to the nearest cent
 func ToCents(f float64) (int64, error) {
-	s := strconv.FormatFloat(f, 'f', 2, 64)
-	sc := strings.Replace(s, ".", "", 1)
-	return strconv.ParseInt(sc, 10, 64)
+	if math.IsNaN(f) || math.IsInf(f, 0) || math.Abs(f) >= math.MaxInt64/100 {
+		return 0, ErrOutOfRange
+	}
+	return ParseMinor(strconv.FormatFloat(f, 'f', 2, 64), 2)
 }
 
 // ToEuro returns the euro representation in float64
 func ToEuro(i int64) (float64, error) {
-	d := strconv.FormatInt(i, 10)
-	df := d[:len(d)-2] + "." + d[len(d)-2:]
-	return strconv.ParseFloat(df, 64)
+	return strconv.ParseFloat(FormatMinor(i, 2), 64)
+}
+
+// AddMinor returns a + b, or ErrOutOfRange if it overflows
+func AddMinor(a int64, b int64) (int64, error) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, ErrOutOfRange
+	}
+	return sum, nil
+}
+
+// SubMinor returns a - b, or ErrOutOfRange if it overflows
+func SubMinor(a int64, b int64) (int64, error) {
+	diff := a - b
+	if (b > 0 && diff > a) || (b < 0 && diff < a) {
+		return 0, ErrOutOfRange
+	}
+	return diff, nil
+}
+
+// MulMinor returns a * b, or ErrOutOfRange if it overflows
+func MulMinor(a int64, b int64) (int64, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	p := a * b
+	if p/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, ErrOutOfRange
+	}
+	return p, nil
+}
+
+// AppendMinor appends minor units with exponent fraction digits to dst, AppendMinor(dst, -50, 2) appends "-0.50".
+// It doesn't allocate when dst has room for the result.
+func AppendMinor(dst []byte, minor int64, exponent int) []byte {
+	if minor < 0 {
+		dst = append(dst, '-')
+	}
+	var buf [20]byte
+	i := len(buf)
+	u := uint64(minor)
+	if minor < 0 {
+		u = -u
+	}
+	for u >= 10 {
+		i--
+		buf[i] = byte('0' + u%10)
+		u /= 10
+	}
+	i--
+	buf[i] = byte('0' + u)
+	digits := buf[i:]
+
+	if exponent <= 0 {
+		dst = append(dst, digits...)
+		for ; exponent < 0; exponent++ {
+			dst = append(dst, '0')
+		}
+		return dst
+	}
+	if len(digits) <= exponent {
+		dst = append(dst, '0', '.')
+		for n := len(digits); n < exponent; n++ {
+			dst = append(dst, '0')
+		}
+		return append(dst, digits...)
+	}
+	dst = append(dst, digits[:len(digits)-exponent]...)
+	dst = append(dst, '.')
+	return append(dst, digits[len(digits)-exponent:]...)
+}
+
+// FormatMinor returns minor units with exponent fraction digits as text, FormatMinor(-50, 2) is "-0.50"
+func FormatMinor(minor int64, exponent int) string {
+	var buf [32]byte
+	return string(AppendMinor(buf[:0], minor, exponent))
+}
+
+// ungroup removes the thousands separators of the integer part of an amount, it tells whether they all are
+// the same and group three digits
+func ungroup(integer string) (string, bool) {
+	i := strings.IndexAny(integer, ",. '\u00a0")
+	if i < 0 {
+		return integer, true
+	}
+	sep, _ := utf8.DecodeRuneInString(integer[i:])
+	groups := strings.Split(integer, string(sep))
+	if len(groups[0]) == 0 || len(groups[0]) > 3 {
+		return integer, false
+	}
+	for _, group := range groups[1:] {
+		if len(group) != 3 {
+			return integer, false
+		}
+	}
+	return strings.Join(groups, ""), true
+}
+
+// ParseMinor reads an amount written as "1234.56", "1234,56", "1,234.56", "1.234,56", "1 234,56" or "1'234.56"
+// and returns it in minor units with exponent fraction digits.
+// When the text is ambiguous, a single separator followed by exactly three digits groups thousands
+// if the amount has less than three fraction digits. Thousands separators must all be the same and group three digits.
+func ParseMinor(s string, exponent int) (int64, error) {
+	text := s
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	if negative || strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+	if s == "" {
+		return 0, errors.New("empty amount")
+	}
+
+	integer, fraction := s, ""
+	if i := strings.LastIndexAny(s, ".,"); i >= 0 {
+		sep := s[i]
+		other := byte(',')
+		if sep == ',' {
+			other = '.'
+		}
+		grouping := strings.Count(s, string(sep)) > 1 ||
+			(!strings.ContainsRune(s[:i], rune(other)) && len(s)-i-1 > exponent && len(s)-i-1 == 3)
+		if grouping && strings.ContainsRune(s, rune(other)) {
+			return 0, errors.New("invalid amount " + text)
+		}
+		if !grouping {
+			integer, fraction = s[:i], s[i+1:]
+			if strings.ContainsRune(integer, rune(sep)) {
+				return 0, errors.New("invalid amount " + text)
+			}
+		}
+	}
+	integer, ok := ungroup(integer)
+	if !ok {
+		return 0, errors.New("invalid amount " + text)
+	}
+	if len(fraction) > exponent {
+		return 0, errors.New("amount " + text + " has more than " + strconv.Itoa(exponent) + " decimals")
+	}
+	if integer == "" && fraction == "" {
+		return 0, errors.New("invalid amount " + text)
+	}
+
+	var minor int64
+	for _, c := range integer + fraction + strings.Repeat("0", exponent-len(fraction)) {
+		if c < '0' || c > '9' {
+			return 0, errors.New("invalid amount " + text)
+		}
+		var err error
+		if minor, err = MulMinor(minor, 10); err != nil {
+			return 0, err
+		}
+		if minor, err = AddMinor(minor, int64(c-'0')); err != nil {
+			return 0, err
+		}
+	}
+	if negative {
+		minor = -minor
+	}
+	return minor, nil
 }
@@ -0,0 +1,119 @@
+package lib
+
+import (
+	"math"
+	"testing"
+)
+
+func TestToEuro(t *testing.T) {
+	TTest := []struct {
+		cents int64
+		euro  float64
+	}{
+		{0, 0},
+		{5, 0.05},
+		{50, 0.5},
+		{-50, -0.5},
+		{12345, 123.45},
+		{-12345, -123.45},
+	}
+	for _, test := range TTest {
+		if e, err := ToEuro(test.cents); err != nil || e != test.euro {
+			t.Error("Expected", test.euro, "got", e, err)
+		}
+	}
+}
+func TestToCents(t *testing.T) {
+	if c, err := ToCents(-0.5); err != nil || c != -50 {
+		t.Error("Expected -50", "got", c, err)
+	}
+	if c, err := ToCents(76.3); err != nil || c != 7630 {
+		t.Error("Expected 7630", "got", c, err)
+	}
+	for _, f := range []float64{1e17, -1e17, math.NaN(), math.Inf(1)} {
+		if _, err := ToCents(f); err != ErrOutOfRange {
+			t.Error("Expected ErrOutOfRange for", f, "got", err)
+		}
+	}
+}
+func TestCheckedArithmetic(t *testing.T) {
+	if _, err := AddMinor(math.MaxInt64, 1); err != ErrOutOfRange {
+		t.Error("Expected AddMinor return ErrOutOfRange", "got", err)
+	}
+	if _, err := SubMinor(math.MinInt64, 1); err != ErrOutOfRange {
+		t.Error("Expected SubMinor return ErrOutOfRange", "got", err)
+	}
+	if _, err := MulMinor(math.MaxInt64/2+1, 2); err != ErrOutOfRange {
+		t.Error("Expected MulMinor return ErrOutOfRange", "got", err)
+	}
+	if _, err := MulMinor(math.MinInt64, -1); err != ErrOutOfRange {
+		t.Error("Expected MulMinor return ErrOutOfRange", "got", err)
+	}
+	if p, err := MulMinor(-1234, 100); err != nil || p != -123400 {
+		t.Error("Expected -123400", "got", p, err)
+	}
+	if d, err := SubMinor(50, 125); err != nil || d != -75 {
+		t.Error("Expected -75", "got", d, err)
+	}
+}
+func TestFormatMinor(t *testing.T) {
+	TTest := []struct {
+		minor    int64
+		exponent int
+		s        string
+	}{
+		{0, 2, "0.00"},
+		{-50, 2, "-0.50"},
+		{7, 3, "0.007"},
+		{7000000, 2, "70000.00"},
+		{763, 0, "763"},
+		{5, -2, "500"},
+		{math.MinInt64, 2, "-92233720368547758.08"},
+	}
+	for _, test := range TTest {
+		if s := FormatMinor(test.minor, test.exponent); s != test.s {
+			t.Error("Expected", test.s, "got", s)
+		}
+	}
+	buf := make([]byte, 0, 32)
+	if n := testing.AllocsPerRun(100, func() { AppendMinor(buf, -123456, 2) }); n != 0 {
+		t.Error("Expected AppendMinor not to allocate", "got", n)
+	}
+}
+func TestParseMinor(t *testing.T) {
+	TTest := []struct {
+		s     string
+		minor int64
+	}{
+		{"1234.56", 123456},
+		{"1234,56", 123456},
+		{"1,234.56", 123456},
+		{"1.234,56", 123456},
+		{"1 234,56", 123456},
+		{"1'234.56", 123456},
+		{"1.234", 123400},
+		{"-0,5", -50},
+		{" +12 ", 1200},
+		{"1.234.567,89", 123456789},
+		{"12 345 678", 1234567800},
+	}
+	for _, test := range TTest {
+		if m, err := ParseMinor(test.s, 2); err != nil || m != test.minor {
+			t.Error("Expected", test.minor, "for", test.s, "got", m, err)
+		}
+	}
+	for _, s := range []string{"", "-", "1.2.3,4,5", "12.345,6.7", "12a", "1.2.3,45", "1234.567,89", "1.23,45", "1 234.567,89", ".123,45"} {
+		if _, err := ParseMinor(s, 2); err == nil {
+			t.Error("Expected ParseMinor return an error for", s)
+		}
+	}
+	if m, err := ParseMinor("1.234", 3); err != nil || m != 1234 {
+		t.Error("Expected ParseMinor read 1.234 as decimals with 3 fraction digits", "got", m, err)
+	}
+	if _, err := ParseMinor("1.2345", 2); err == nil {
+		t.Error("Expected ParseMinor return an error for too many decimals")
+	}
+	if _, err := ParseMinor("99999999999999999999", 2); err != ErrOutOfRange {
+		t.Error("Expected ErrOutOfRange", "got", err)
+	}
+}
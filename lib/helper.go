@@ -1,26 +1,46 @@
 package lib
 
 import (
+	"bytes"
 	"encoding/xml"
+	"io"
 	"math/big"
 )
 
 // Serialize returns the xml document in byte stream
 func Serialize(doc interface{}) ([]byte, error) {
-	res, err := xml.Marshal(doc)
-	if err != nil {
+	var buf bytes.Buffer
+	if err := SerializeTo(&buf, doc); err != nil {
 		return nil, err
 	}
-	return []byte(xml.Header + string(res)), nil
+	return buf.Bytes(), nil
 }
 
 // PrettySerialize returns the indented xml document in byte stream
 func PrettySerialize(doc interface{}) ([]byte, error) {
-	res, err := xml.MarshalIndent(doc, "", "  ")
-	if err != nil {
+	var buf bytes.Buffer
+	if err := PrettySerializeTo(&buf, doc); err != nil {
 		return nil, err
 	}
-	return []byte(xml.Header + string(res)), nil
+	return buf.Bytes(), nil
+}
+
+// SerializeTo writes the xml document to w
+func SerializeTo(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(doc)
+}
+
+// PrettySerializeTo writes the indented xml document to w
+func PrettySerializeTo(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(doc)
 }
 
 // IsValid IBAN
@@ -0,0 +1,189 @@
+package sepa
+
+import (
+	"errors"
+	"github.com/flofuenf/gosepa/lib"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amount is an exact decimal amount : a number of minor units and the number of fraction digits they stand for.
+// The zero value is an amount of 0.
+type Amount struct {
+	minor    int64
+	exponent int
+}
+
+// currencyExponents lists the ISO 4217 currencies whose minor unit is not the cent
+var currencyExponents = map[string]int{
+	"BHD": 3, "BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "IQD": 3, "ISK": 0, "JOD": 3, "JPY": 0, "KMF": 0,
+	"KRW": 0, "KWD": 3, "LYD": 3, "OMR": 3, "PYG": 0, "RWF": 0, "TND": 3, "UGX": 0, "UYI": 0, "VND": 0,
+	"VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+// CurrencyExponent returns the number of fraction digits of a currency, 2 for EUR and most others
+func CurrencyExponent(currency string) int {
+	if e, ok := currencyExponents[currency]; ok {
+		return e
+	}
+	return 2
+}
+
+// NewAmount returns the amount of minor units with exponent fraction digits, NewAmount(1050, 2) is 10.50
+func NewAmount(minor int64, exponent int) Amount {
+	return Amount{minor: minor, exponent: exponent}
+}
+
+// AmountOf returns the amount of minor units of a currency, AmountOf(1050, "EUR") is 10.50 EUR
+func AmountOf(minor int64, currency string) Amount {
+	return NewAmount(minor, CurrencyExponent(currency))
+}
+
+// ParseAmount reads an amount of a currency written as "1234.56", "1234,56", "1,234.56" or "1.234,56".
+// When the text is ambiguous, a separator followed by more digits than the currency has fraction digits groups thousands.
+func ParseAmount(s string, currency string) (Amount, error) {
+	exponent := CurrencyExponent(currency)
+	minor, err := lib.ParseMinor(s, exponent)
+	if err != nil {
+		return Amount{}, errors.New(err.Error() + " for " + currency)
+	}
+	return NewAmount(minor, exponent), nil
+}
+
+// Minor returns the amount in minor units
+func (a Amount) Minor() int64 {
+	return a.minor
+}
+
+// Exponent returns the number of fraction digits of the minor units
+func (a Amount) Exponent() int {
+	return a.exponent
+}
+
+// IsZero tells whether the amount is 0
+func (a Amount) IsZero() bool {
+	return a.minor == 0
+}
+
+// Neg returns the opposite amount
+func (a Amount) Neg() Amount {
+	return Amount{minor: -a.minor, exponent: a.exponent}
+}
+
+// Rescale returns the same amount with exponent fraction digits, or an error if it would lose digits or overflow
+func (a Amount) Rescale(exponent int) (Amount, error) {
+	minor := a.minor
+	for e := a.exponent; e < exponent; e++ {
+		var err error
+		if minor, err = lib.MulMinor(minor, 10); err != nil {
+			return Amount{}, err
+		}
+	}
+	for e := a.exponent; e > exponent; e-- {
+		if minor%10 != 0 {
+			return Amount{}, errors.New("amount " + a.String() + " has more than " + strconv.Itoa(exponent) + " decimals")
+		}
+		minor /= 10
+	}
+	return Amount{minor: minor, exponent: exponent}, nil
+}
+
+// Add returns a + b with the fraction digits of the more precise one
+func (a Amount) Add(b Amount) (Amount, error) {
+	exponent := a.exponent
+	if b.exponent > exponent {
+		exponent = b.exponent
+	}
+	a, err := a.Rescale(exponent)
+	if err != nil {
+		return Amount{}, err
+	}
+	b, err = b.Rescale(exponent)
+	if err != nil {
+		return Amount{}, err
+	}
+	sum, err := lib.AddMinor(a.minor, b.minor)
+	if err != nil {
+		return Amount{}, err
+	}
+	return Amount{minor: sum, exponent: exponent}, nil
+}
+
+// Sub returns a - b with the fraction digits of the more precise one
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if b.minor == math.MinInt64 {
+		return Amount{}, lib.ErrOutOfRange
+	}
+	return a.Add(b.Neg())
+}
+
+// Cmp compares a and b, it returns -1 if a < b, 0 if a == b and +1 if a > b
+func (a Amount) Cmp(b Amount) int {
+	d, err := a.Sub(b)
+	if err != nil {
+		// out of range only when the signs differ
+		if a.minor < b.minor {
+			return -1
+		}
+		return 1
+	}
+	switch {
+	case d.minor < 0:
+		return -1
+	case d.minor > 0:
+		return 1
+	}
+	return 0
+}
+
+// Float64 returns the nearest float64, for display or computations that don't need to be exact
+func (a Amount) Float64() float64 {
+	f, _ := strconv.ParseFloat(a.String(), 64)
+	return f
+}
+
+// String writes the amount with all its fraction digits, "10.50"
+func (a Amount) String() string {
+	return lib.FormatMinor(a.minor, a.exponent)
+}
+
+// checkAmount returns the amount of a transaction in the fraction digits of its currency, or an error if it has more
+// or isn't positive
+func checkAmount(a Amount, currency string) (Amount, error) {
+	a, err := a.Rescale(CurrencyExponent(currency))
+	if err != nil {
+		return a, err
+	}
+	if a.minor <= 0 {
+		return a, errors.New("amount " + a.String() + " is not positive")
+	}
+	return a, nil
+}
+
+// MarshalText writes the amount with at least two fraction digits as banks expect control sums, "10.50"
+func (a Amount) MarshalText() ([]byte, error) {
+	if a.exponent < 2 {
+		var err error
+		if a, err = a.Rescale(2); err != nil {
+			return nil, err
+		}
+	}
+	return lib.AppendMinor(nil, a.minor, a.exponent), nil
+}
+
+// UnmarshalText reads an amount written with a decimal point, keeping as many fraction digits as written
+func (a *Amount) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	exponent := 0
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		exponent = len(s) - i - 1
+		s = s[:i] + s[i+1:]
+	}
+	minor, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return errors.New("invalid amount " + string(text))
+	}
+	*a = Amount{minor: minor, exponent: exponent}
+	return nil
+}
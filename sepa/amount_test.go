@@ -0,0 +1,122 @@
+package sepa
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// eur returns the EUR amount written in s, for test tables
+func eur(s string) Amount {
+	a, err := ParseAmount(s, "EUR")
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func TestParseAmount(t *testing.T) {
+	suite := []struct {
+		s        string
+		currency string
+		minor    int64
+		exponent int
+		err      bool
+	}{
+		{"1234.56", "EUR", 123456, 2, false},
+		{"1234,56", "EUR", 123456, 2, false},
+		{"1,234.56", "EUR", 123456, 2, false},
+		{"1.234,56", "EUR", 123456, 2, false},
+		{"1 234,5", "EUR", 123450, 2, false},
+		{"1.234.567", "EUR", 123456700, 2, false},
+		{"1.234", "EUR", 123400, 2, false},
+		{"-0.5", "EUR", -50, 2, false},
+		{"70000", "EUR", 7000000, 2, false},
+		{"1.234", "BHD", 1234, 3, false},
+		{"1,234", "JPY", 1234, 0, false},
+		{"1.234,567", "EUR", 0, 0, true},
+		{"12.5", "JPY", 0, 0, true},
+		{"1,234.5.6", "EUR", 0, 0, true},
+		{"12a", "EUR", 0, 0, true},
+		{"", "EUR", 0, 0, true},
+		{"999999999999999999", "EUR", 0, 0, true},
+	}
+	for _, s := range suite {
+		a, err := ParseAmount(s.s, s.currency)
+		if s.err {
+			if err == nil {
+				t.Error("Expected ParseAmount return an error for", s.s, "got", a)
+			}
+			continue
+		}
+		if err != nil || a.Minor() != s.minor || a.Exponent() != s.exponent {
+			t.Error("Expected", s.minor, s.exponent, "for", s.s, "got", a.Minor(), a.Exponent(), err)
+		}
+	}
+}
+func TestAmountArithmetic(t *testing.T) {
+	sum, err := eur("0.1").Add(eur("0.2"))
+	if err != nil || sum.Cmp(eur("0.3")) != 0 || sum.String() != "0.30" {
+		t.Error("Expected 0.30", "got", sum, err)
+	}
+	if d, err := NewAmount(5, 1).Sub(eur("1.25")); err != nil || d.String() != "-0.75" {
+		t.Error("Expected -0.75", "got", d, err)
+	}
+	if _, err := NewAmount(1234, 3).Rescale(2); err == nil {
+		t.Error("Expected Rescale return an error for lost digits")
+	}
+	if _, err := NewAmount(1<<62, 2).Add(NewAmount(1<<62, 2)); err == nil {
+		t.Error("Expected Add return an error for overflow")
+	}
+	if NewAmount(105, 1).Cmp(eur("10.49")) != 1 || AmountOf(5, "JPY").String() != "5" || AmountOf(5, "EUR").String() != "0.05" {
+		t.Error("Expected Cmp and String to honour exponents")
+	}
+	var a Amount
+	if err := a.UnmarshalText([]byte(" 76.30 ")); err != nil || a.Cmp(eur("76.3")) != 0 {
+		t.Error("Expected 76.30", "got", a, err)
+	}
+	if b, _ := NewAmount(763, 1).MarshalText(); string(b) != "76.30" {
+		t.Error("Expected 76.30", "got", string(b))
+	}
+	if b, _ := NewAmount(1, 3).MarshalText(); string(b) != "0.001" {
+		t.Error("Expected 0.001", "got", string(b))
+	}
+	if _, err := NewAmount(math.MaxInt64, 0).MarshalText(); err == nil {
+		t.Error("Expected MarshalText return an error for an amount out of range")
+	}
+}
+func TestAmountXML(t *testing.T) {
+	doc := &CreditTransfer{}
+	if err := doc.InitDoc("MSGID", "PMT", "2017-06-07T14:39:33", "2017-06-11", "Emitter Name", "FR1420041010050500013M02606", "BKAUATWW", "DE", "some street", "some city"); err != nil {
+		t.Fatal("Expected InitDoc return nil", "got", err)
+	}
+	if err := doc.AddTransaction("E2E-1", NewAmount(9000000000000000, 0), "EUR", "Creditor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice"); err != nil {
+		t.Fatal("Expected AddTransaction return nil", "got", err)
+	}
+	if err := doc.AddTransaction("E2E-2", NewAmount(763, 1), "EUR", "Creditor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice"); err != nil {
+		t.Fatal("Expected AddTransaction return nil", "got", err)
+	}
+	if err := doc.AddTransaction("E2E-3", AmountOf(763, "JPY"), "JPY", "Creditor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice"); err != nil {
+		t.Fatal("Expected AddTransaction return nil", "got", err)
+	}
+	if err := doc.AddTransaction("E2E-4", eur("0.5"), "EUR", "Creditor", "GB29NWBK60161331926819", "BFAUAUWA", "Refund"); err != nil {
+		t.Fatal("Expected AddTransaction return nil", "got", err)
+	}
+	for _, serialize := range []func() ([]byte, error){doc.Serialize, doc.PrettySerialize} {
+		str, err := serialize()
+		if err != nil {
+			t.Fatal("Expected xml in []byte, got ", err)
+		}
+		for _, c := range []string{
+			`<InstdAmt Ccy="EUR">9000000000000000.00</InstdAmt>`,
+			`<InstdAmt Ccy="EUR">76.30</InstdAmt>`,
+			`<InstdAmt Ccy="EUR">0.50</InstdAmt>`,
+			`<InstdAmt Ccy="JPY">763</InstdAmt>`,
+			"<CtrlSum>9000000000000839.80</CtrlSum>",
+		} {
+			if !strings.Contains(string(str), c) {
+				t.Error("Expected", c, "in", string(str))
+			}
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package sepa
+
+import (
+	"strings"
+)
+
+// Credit/debit indicators of camt amounts
+const (
+	Credit = "CRDT"
+	Debit  = "DBIT"
+)
+
+// Entry is a booking (Ntry) on the account of a camt statement or notification
+type Entry struct {
+	Reference           string
+	Amount              TAmount
+	CreditDebit         string
+	Reversal            bool
+	Status              string
+	BookingDate         string
+	ValueDate           string
+	AccountServicerRef  string
+	BankTransactionCode BankTransactionCode
+	AdditionalInfo      string
+	Batches             []EntryBatch
+	Details             []EntryDetails
+}
+
+// EntryBatch is the batch (Btch) an entry books at once, as sent with BtchBookg=true
+type EntryBatch struct {
+	MsgID         string
+	PaymentInfoID string
+	TransactNo    int
+	TotalAmount   TAmount
+	CreditDebit   string
+}
+
+// BankTransactionCode is the ISO domain/family/sub-family code of an entry, or the proprietary code of the bank
+type BankTransactionCode struct {
+	Domain      string
+	Family      string
+	SubFamily   string
+	Proprietary string
+}
+
+// EntryDetails is a single transaction (TxDtls) booked in an entry
+type EntryDetails struct {
+	MsgID              string
+	AccountServicerRef string
+	PaymentInfoID      string
+	InstructionID      string
+	EndToEndID         string
+	TransactionID      string
+	MandateID          string
+	Amount             TAmount
+	CreditDebit        string
+	Debtor             RelatedParty
+	Creditor           RelatedParty
+	CreditorSchemeID   string
+	RemittanceInfo     []string
+	CreditorReference  string
+	ReturnReason       string
+	ReturnInfo         []string
+}
+
+// BookedTransaction is a single transaction of an entry, batch entries being expanded into their transaction details
+type BookedTransaction struct {
+	EntryDetails
+	Entry *Entry
+}
+
+// Transactions expands the entry into its booked transactions.
+// An entry without transaction details is a single transaction of the entry amount.
+func (e *Entry) Transactions() []BookedTransaction {
+	if len(e.Details) == 0 {
+		d := EntryDetails{Amount: e.Amount, CreditDebit: e.CreditDebit, AccountServicerRef: e.AccountServicerRef}
+		if len(e.Batches) == 1 {
+			d.MsgID = e.Batches[0].MsgID
+			d.PaymentInfoID = e.Batches[0].PaymentInfoID
+		}
+		return []BookedTransaction{{EntryDetails: d, Entry: e}}
+	}
+	transactions := make([]BookedTransaction, 0, len(e.Details))
+	for _, d := range e.Details {
+		transactions = append(transactions, BookedTransaction{EntryDetails: d, Entry: e})
+	}
+	return transactions
+}
+
+// RelatedParty is the name, account and agent of a debtor or creditor of a booked transaction
+type RelatedParty struct {
+	Name string
+	IBAN string
+	BIC  string
+}
+
+// Counterparty returns the other side of the transaction : the debtor of a credit, the creditor of a debit
+func (d *EntryDetails) Counterparty() RelatedParty {
+	if d.CreditDebit == Debit {
+		return d.Creditor
+	}
+	return d.Debtor
+}
+
+// Returned tells whether the transaction is the return of a payment
+func (d *EntryDetails) Returned() bool {
+	return d.ReturnReason != ""
+}
+
+// camtAccountReport is the layout shared by camt.053 Stmt and camt.054 Ntfctn.
+// Elements renamed between versions are read through both names.
+type camtAccountReport struct {
+	ID              string        `xml:"Id"`
+	ElectronicSeqNo string        `xml:"ElctrncSeqNb"`
+	CreationDate    string        `xml:"CreDtTm"`
+	FromDate        string        `xml:"FrToDt>FrDtTm"`
+	ToDate          string        `xml:"FrToDt>ToDtTm"`
+	Account         camtAccount   `xml:"Acct"`
+	Balances        []camtBalance `xml:"Bal"`
+	EntryCount      *int          `xml:"TxsSummry>TtlNtries>NbOfNtries"`
+	EntrySum        *Amount       `xml:"TxsSummry>TtlNtries>Sum"`
+	Entries         []camtEntry   `xml:"Ntry"`
+}
+
+// camtBalance is the layout of a balance (Bal)
+type camtBalance struct {
+	Type        string   `xml:"Tp>CdOrPrtry>Cd"`
+	Amount      TAmount  `xml:"Amt"`
+	CreditDebit string   `xml:"CdtDbtInd"`
+	Date        camtDate `xml:"Dt"`
+}
+
+// camtEntry is the layout of an entry (Ntry)
+type camtEntry struct {
+	Reference           string             `xml:"NtryRef"`
+	Amount              TAmount            `xml:"Amt"`
+	CreditDebit         string             `xml:"CdtDbtInd"`
+	Reversal            bool               `xml:"RvslInd"`
+	Status              camtCode           `xml:"Sts"`
+	BookingDate         camtDate           `xml:"BookgDt"`
+	ValueDate           camtDate           `xml:"ValDt"`
+	AccountServicerRef  string             `xml:"AcctSvcrRef"`
+	Domain              string             `xml:"BkTxCd>Domn>Cd"`
+	Family              string             `xml:"BkTxCd>Domn>Fmly>Cd"`
+	SubFamily           string             `xml:"BkTxCd>Domn>Fmly>SubFmlyCd"`
+	Proprietary         string             `xml:"BkTxCd>Prtry>Cd"`
+	Details             []camtEntryDetails `xml:"NtryDtls"`
+	AdditionalEntryInfo string             `xml:"AddtlNtryInf"`
+}
+
+// camtEntryDetails is the layout of the entry details (NtryDtls), repeated from camt.053.001.04 on
+type camtEntryDetails struct {
+	Batch        *camtBatch        `xml:"Btch"`
+	Transactions []camtTransaction `xml:"TxDtls"`
+}
+
+// camtBatch is the layout of a batch (Btch)
+type camtBatch struct {
+	MsgID         string  `xml:"MsgId"`
+	PaymentInfoID string  `xml:"PmtInfId"`
+	TransactNo    int     `xml:"NbOfTxs"`
+	TotalAmount   TAmount `xml:"TtlAmt"`
+	CreditDebit   string  `xml:"CdtDbtInd"`
+}
+
+// camtTransaction is the layout of a transaction detail (TxDtls)
+type camtTransaction struct {
+	MsgID              string      `xml:"Refs>MsgId"`
+	AccountServicerRef string      `xml:"Refs>AcctSvcrRef"`
+	PaymentInfoID      string      `xml:"Refs>PmtInfId"`
+	InstructionID      string      `xml:"Refs>InstrId"`
+	EndToEndID         string      `xml:"Refs>EndToEndId"`
+	TransactionID      string      `xml:"Refs>TxId"`
+	MandateID          string      `xml:"Refs>MndtId"`
+	Amount             *TAmount    `xml:"Amt"`
+	TransactionAmount  *TAmount    `xml:"AmtDtls>TxAmt>Amt"`
+	CreditDebit        string      `xml:"CdtDbtInd"`
+	Debtor             camtParty   `xml:"RltdPties>Dbtr"`
+	DebtorAccount      camtAccount `xml:"RltdPties>DbtrAcct"`
+	Creditor           camtParty   `xml:"RltdPties>Cdtr"`
+	CreditorAccount    camtAccount `xml:"RltdPties>CdtrAcct"`
+	DebtorAgent        camtAgent   `xml:"RltdAgts>DbtrAgt"`
+	CreditorAgent      camtAgent   `xml:"RltdAgts>CdtrAgt"`
+	Unstructured       []string    `xml:"RmtInf>Ustrd"`
+	CreditorReference  string      `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+	ReturnReason       string      `xml:"RtrInf>Rsn>Cd"`
+	ReturnInfo         []string    `xml:"RtrInf>AddtlInf"`
+}
+
+// camtCode is a code given as text (Sts up to V04) or as Cd child (Sts from V08 on)
+type camtCode struct {
+	Text string `xml:",chardata"`
+	Code string `xml:"Cd"`
+}
+
+func (c camtCode) String() string {
+	if c.Code != "" {
+		return c.Code
+	}
+	return strings.TrimSpace(c.Text)
+}
+
+// camtDate is a date given as Dt or DtTm
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+func (d camtDate) String() string {
+	if d.Date != "" {
+		return d.Date
+	}
+	return d.DateTime
+}
+
+// camtParty is a party, named directly up to V04 and through Pty from V08 on
+type camtParty struct {
+	Name      string `xml:"Nm"`
+	ID        string `xml:"Id>PrvtId>Othr>Id"`
+	PartyName string `xml:"Pty>Nm"`
+	PartyID   string `xml:"Pty>Id>PrvtId>Othr>Id"`
+}
+
+// camtAccount is an account with its currency and servicing agent
+type camtAccount struct {
+	IBAN     string    `xml:"Id>IBAN"`
+	Other    string    `xml:"Id>Othr>Id"`
+	Currency string    `xml:"Ccy"`
+	Servicer camtAgent `xml:"Svcr"`
+}
+
+// camtAgent is a financial institution, identified by BIC up to V04 and BICFI from V08 on
+type camtAgent struct {
+	BIC   string `xml:"FinInstnId>BIC"`
+	BICFI string `xml:"FinInstnId>BICFI"`
+}
+
+func (a camtAgent) String() string {
+	if a.BICFI != "" {
+		return a.BICFI
+	}
+	return a.BIC
+}
+
+// entries maps the entries of the layout
+func (r *camtAccountReport) entries() []Entry {
+	var entries []Entry
+	for _, e := range r.Entries {
+		entries = append(entries, e.entry())
+	}
+	return entries
+}
+
+// entry maps the layout onto an Entry. Transaction details missing amount or indicator take the ones of the entry,
+// missing message or payment info ID the ones of their batch.
+func (e *camtEntry) entry() Entry {
+	entry := Entry{
+		Reference:          e.Reference,
+		Amount:             e.Amount,
+		CreditDebit:        e.CreditDebit,
+		Reversal:           e.Reversal,
+		Status:             e.Status.String(),
+		BookingDate:        e.BookingDate.String(),
+		ValueDate:          e.ValueDate.String(),
+		AccountServicerRef: e.AccountServicerRef,
+		BankTransactionCode: BankTransactionCode{
+			Domain:      e.Domain,
+			Family:      e.Family,
+			SubFamily:   e.SubFamily,
+			Proprietary: e.Proprietary,
+		},
+		AdditionalInfo: e.AdditionalEntryInfo,
+	}
+	for _, d := range e.Details {
+		for _, t := range d.Transactions {
+			details := t.details()
+			if d.Batch != nil {
+				if details.MsgID == "" {
+					details.MsgID = d.Batch.MsgID
+				}
+				if details.PaymentInfoID == "" {
+					details.PaymentInfoID = d.Batch.PaymentInfoID
+				}
+			}
+			entry.Details = append(entry.Details, details)
+		}
+		if d.Batch != nil {
+			entry.Batches = append(entry.Batches, EntryBatch(*d.Batch))
+		}
+	}
+	for i := range entry.Details {
+		if entry.Details[i].CreditDebit == "" {
+			entry.Details[i].CreditDebit = entry.CreditDebit
+		}
+	}
+	if len(entry.Details) == 1 && entry.Details[0].Amount.Currency == "" {
+		entry.Details[0].Amount = entry.Amount
+	}
+	return entry
+}
+
+// details maps the layout onto EntryDetails
+func (t *camtTransaction) details() EntryDetails {
+	d := EntryDetails{
+		MsgID:              t.MsgID,
+		AccountServicerRef: t.AccountServicerRef,
+		PaymentInfoID:      t.PaymentInfoID,
+		InstructionID:      t.InstructionID,
+		EndToEndID:         t.EndToEndID,
+		TransactionID:      t.TransactionID,
+		MandateID:          t.MandateID,
+		CreditDebit:        t.CreditDebit,
+		Debtor:             t.Debtor.relatedParty(t.DebtorAccount, t.DebtorAgent),
+		Creditor:           t.Creditor.relatedParty(t.CreditorAccount, t.CreditorAgent),
+		CreditorSchemeID:   t.Creditor.ID,
+		RemittanceInfo:     t.Unstructured,
+		CreditorReference:  t.CreditorReference,
+		ReturnReason:       t.ReturnReason,
+		ReturnInfo:         t.ReturnInfo,
+	}
+	if t.Creditor.PartyID != "" {
+		d.CreditorSchemeID = t.Creditor.PartyID
+	}
+	switch {
+	case t.Amount != nil:
+		d.Amount = *t.Amount
+	case t.TransactionAmount != nil:
+		d.Amount = *t.TransactionAmount
+	}
+	return d
+}
+
+// relatedParty maps a party with its account and agent onto a RelatedParty
+func (p camtParty) relatedParty(account camtAccount, agent camtAgent) RelatedParty {
+	r := RelatedParty{Name: p.Name, IBAN: account.IBAN, BIC: agent.String()}
+	if p.PartyName != "" {
+		r.Name = p.PartyName
+	}
+	return r
+}
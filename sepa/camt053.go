@@ -0,0 +1,120 @@
+package sepa
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// BankStatement is a camt.053 bank to customer statement
+type BankStatement struct {
+	Version      string
+	MsgID        string
+	CreationDate string
+	Statements   []AccountStatement
+}
+
+// AccountStatement is the statement (Stmt) of one account over a period
+type AccountStatement struct {
+	ID              string
+	ElectronicSeqNo string
+	CreationDate    string
+	FromDate        string
+	ToDate          string
+	AccountIBAN     string
+	AccountCurrency string
+	AccountBIC      string
+	Balances        []Balance
+	Entries         []Entry
+}
+
+// Balance is a balance (Bal) of a statement
+type Balance struct {
+	Type        string
+	Amount      TAmount
+	CreditDebit string
+	Date        string
+}
+
+// Balance types of a statement
+const (
+	BalanceOpening = "OPBD"
+	BalanceClosing = "CLBD"
+)
+
+// Balance returns the balance of the given type (BalanceOpening, BalanceClosing...), nil if there is none
+func (s *AccountStatement) Balance(balanceType string) *Balance {
+	for i := range s.Balances {
+		if s.Balances[i].Type == balanceType {
+			return &s.Balances[i]
+		}
+	}
+	return nil
+}
+
+// Transactions returns the booked transactions of all entries, batch entries expanded
+func (s *AccountStatement) Transactions() []BookedTransaction {
+	var transactions []BookedTransaction
+	for i := range s.Entries {
+		transactions = append(transactions, s.Entries[i].Transactions()...)
+	}
+	return transactions
+}
+
+// camt053 is the layout of a camt.053 document
+type camt053 struct {
+	XMLName      xml.Name            `xml:"Document"`
+	MsgID        string              `xml:"BkToCstmrStmt>GrpHdr>MsgId"`
+	CreationDate string              `xml:"BkToCstmrStmt>GrpHdr>CreDtTm"`
+	Statements   []camtAccountReport `xml:"BkToCstmrStmt>Stmt"`
+}
+
+// ParseBankStatement reads a camt.053 document in any supported schema version
+func ParseBankStatement(r io.Reader) (*BankStatement, error) {
+	d := xml.NewDecoder(r)
+	start, err := rootElement(d)
+	if err != nil {
+		return nil, err
+	}
+	version := versionOf(start.Name.Space)
+	if err := checkVersion(version, Camt053V02, Camt053V04, Camt053V08); err != nil {
+		return nil, err
+	}
+	layout := &camt053{}
+	if err := d.DecodeElement(layout, &start); err != nil {
+		return nil, err
+	}
+
+	doc := &BankStatement{
+		Version:      version,
+		MsgID:        layout.MsgID,
+		CreationDate: layout.CreationDate,
+	}
+	for i := range layout.Statements {
+		doc.Statements = append(doc.Statements, layout.Statements[i].accountStatement())
+	}
+	return doc, nil
+}
+
+// accountStatement maps the layout of a Stmt onto an AccountStatement
+func (r *camtAccountReport) accountStatement() AccountStatement {
+	stmt := AccountStatement{
+		ID:              r.ID,
+		ElectronicSeqNo: r.ElectronicSeqNo,
+		CreationDate:    r.CreationDate,
+		FromDate:        r.FromDate,
+		ToDate:          r.ToDate,
+		AccountIBAN:     r.Account.IBAN,
+		AccountCurrency: r.Account.Currency,
+		AccountBIC:      r.Account.Servicer.String(),
+		Entries:         r.entries(),
+	}
+	for _, b := range r.Balances {
+		stmt.Balances = append(stmt.Balances, Balance{
+			Type:        b.Type,
+			Amount:      b.Amount,
+			CreditDebit: b.CreditDebit,
+			Date:        b.Date.String(),
+		})
+	}
+	return stmt
+}
@@ -0,0 +1,82 @@
+package sepa
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// BankNotification is a camt.054 bank to customer debit credit notification
+type BankNotification struct {
+	Version       string
+	MsgID         string
+	CreationDate  string
+	Notifications []AccountNotification
+}
+
+// AccountNotification is the notification (Ntfctn) of the entries booked on one account
+type AccountNotification struct {
+	ID              string
+	ElectronicSeqNo string
+	CreationDate    string
+	AccountIBAN     string
+	AccountCurrency string
+	AccountBIC      string
+	Entries         []Entry
+}
+
+// Transactions returns the booked transactions of all entries, batch entries expanded
+func (n *AccountNotification) Transactions() []BookedTransaction {
+	var transactions []BookedTransaction
+	for i := range n.Entries {
+		transactions = append(transactions, n.Entries[i].Transactions()...)
+	}
+	return transactions
+}
+
+// camt054 is the layout of a camt.054 document
+type camt054 struct {
+	XMLName       xml.Name            `xml:"Document"`
+	MsgID         string              `xml:"BkToCstmrDbtCdtNtfctn>GrpHdr>MsgId"`
+	CreationDate  string              `xml:"BkToCstmrDbtCdtNtfctn>GrpHdr>CreDtTm"`
+	Notifications []camtAccountReport `xml:"BkToCstmrDbtCdtNtfctn>Ntfctn"`
+}
+
+// ParseBankNotification reads a camt.054 document in any supported schema version
+func ParseBankNotification(r io.Reader) (*BankNotification, error) {
+	d := xml.NewDecoder(r)
+	start, err := rootElement(d)
+	if err != nil {
+		return nil, err
+	}
+	version := versionOf(start.Name.Space)
+	if err := checkVersion(version, Camt054V02, Camt054V04, Camt054V08); err != nil {
+		return nil, err
+	}
+	layout := &camt054{}
+	if err := d.DecodeElement(layout, &start); err != nil {
+		return nil, err
+	}
+
+	doc := &BankNotification{
+		Version:      version,
+		MsgID:        layout.MsgID,
+		CreationDate: layout.CreationDate,
+	}
+	for i := range layout.Notifications {
+		doc.Notifications = append(doc.Notifications, layout.Notifications[i].accountNotification())
+	}
+	return doc, nil
+}
+
+// accountNotification maps the layout of a Ntfctn onto an AccountNotification
+func (r *camtAccountReport) accountNotification() AccountNotification {
+	return AccountNotification{
+		ID:              r.ID,
+		ElectronicSeqNo: r.ElectronicSeqNo,
+		CreationDate:    r.CreationDate,
+		AccountIBAN:     r.Account.IBAN,
+		AccountCurrency: r.Account.Currency,
+		AccountBIC:      r.Account.Servicer.String(),
+		Entries:         r.entries(),
+	}
+}
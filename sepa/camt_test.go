@@ -0,0 +1,214 @@
+package sepa
+
+import (
+	"strings"
+	"testing"
+)
+
+const camt053V02Doc = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <BkToCstmrStmt>
+    <GrpHdr><MsgId>STMT-2017-06-12</MsgId><CreDtTm>2017-06-12T06:00:00</CreDtTm></GrpHdr>
+    <Stmt>
+      <Id>STMT-1</Id>
+      <ElctrncSeqNb>112</ElctrncSeqNb>
+      <CreDtTm>2017-06-12T06:00:00</CreDtTm>
+      <FrToDt><FrDtTm>2017-06-11T00:00:00</FrDtTm><ToDtTm>2017-06-11T23:59:59</ToDtTm></FrToDt>
+      <Acct><Id><IBAN>FR1420041010050500013M02606</IBAN></Id><Ccy>EUR</Ccy><Svcr><FinInstnId><BIC>BKAUATWW</BIC></FinInstnId></Svcr></Acct>
+      <Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt Ccy="EUR">1000.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2017-06-11</Dt></Dt></Bal>
+      <Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt Ccy="EUR">1020.50</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2017-06-11</Dt></Dt></Bal>
+      <Ntry>
+        <Amt Ccy="EUR">30.50</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts>BOOK</Sts>
+        <BookgDt><Dt>2017-06-11</Dt></BookgDt><ValDt><Dt>2017-06-11</Dt></ValDt>
+        <AcctSvcrRef>REF-1</AcctSvcrRef>
+        <BkTxCd><Domn><Cd>PMNT</Cd><Fmly><Cd>RDDT</Cd><SubFmlyCd>ESDD</SubFmlyCd></Fmly></Domn></BkTxCd>
+        <NtryDtls>
+          <TxDtls>
+            <Refs><PmtInfId>PMT</PmtInfId><EndToEndId>E2E-1</EndToEndId><MndtId>MNDT-1</MndtId></Refs>
+            <AmtDtls><TxAmt><Amt Ccy="EUR">10.00</Amt></TxAmt></AmtDtls>
+            <RltdPties><Dbtr><Nm>Debtor</Nm></Dbtr><DbtrAcct><Id><IBAN>GB29NWBK60161331926819</IBAN></Id></DbtrAcct><Cdtr><Nm>Emitter Name</Nm><Id><PrvtId><Othr><Id>DE98ZZZ09999999999</Id></Othr></PrvtId></Id></Cdtr></RltdPties>
+            <RltdAgts><DbtrAgt><FinInstnId><BIC>BFAUAUWA</BIC></FinInstnId></DbtrAgt></RltdAgts>
+            <RmtInf><Ustrd>Invoice</Ustrd></RmtInf>
+          </TxDtls>
+          <TxDtls>
+            <Refs><EndToEndId>E2E-2</EndToEndId><MndtId>MNDT-2</MndtId></Refs>
+            <AmtDtls><TxAmt><Amt Ccy="EUR">20.50</Amt></TxAmt></AmtDtls>
+            <RltdPties><Dbtr><Nm>Debtor 2</Nm></Dbtr><DbtrAcct><Id><IBAN>BE62510007547061</IBAN></Id></DbtrAcct></RltdPties>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">10.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts>BOOK</Sts>
+        <BookgDt><Dt>2017-06-11</Dt></BookgDt><ValDt><Dt>2017-06-11</Dt></ValDt>
+        <BkTxCd><Domn><Cd>PMNT</Cd><Fmly><Cd>RDDT</Cd><SubFmlyCd>UPDD</SubFmlyCd></Fmly></Domn></BkTxCd>
+        <NtryDtls>
+          <TxDtls>
+            <Refs><EndToEndId>E2E-0</EndToEndId><MndtId>MNDT-0</MndtId></Refs>
+            <RltdPties><Dbtr><Nm>Debtor 0</Nm></Dbtr><DbtrAcct><Id><IBAN>AT611904300234573201</IBAN></Id></DbtrAcct></RltdPties>
+            <RtrInf><Rsn><Cd>AC04</Cd></Rsn><AddtlInf>Account closed</AddtlInf></RtrInf>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+const camt053V08Doc = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08">
+  <BkToCstmrStmt>
+    <GrpHdr><MsgId>STMT-2023</MsgId><CreDtTm>2023-11-20T06:00:00+01:00</CreDtTm></GrpHdr>
+    <Stmt>
+      <Id>STMT-2</Id>
+      <Acct><Id><IBAN>FR1420041010050500013M02606</IBAN></Id><Ccy>EUR</Ccy><Svcr><FinInstnId><BICFI>BKAUATWW</BICFI></FinInstnId></Svcr></Acct>
+      <Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt Ccy="EUR">100.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Dt><Dt>2023-11-19</Dt></Dt></Bal>
+      <Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt Ccy="EUR">170.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Dt><Dt>2023-11-19</Dt></Dt></Bal>
+      <Ntry>
+        <Amt Ccy="EUR">70.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><DtTm>2023-11-19T10:00:00+01:00</DtTm></BookgDt><ValDt><Dt>2023-11-19</Dt></ValDt>
+        <BkTxCd><Domn><Cd>PMNT</Cd><Fmly><Cd>ICDT</Cd><SubFmlyCd>ESCT</SubFmlyCd></Fmly></Domn></BkTxCd>
+        <NtryDtls>
+          <TxDtls>
+            <Refs><PmtInfId>PMT-1</PmtInfId><EndToEndId>F1</EndToEndId></Refs>
+            <Amt Ccy="EUR">70.00</Amt>
+            <RltdPties><Cdtr><Pty><Nm>DEF Electronics</Nm></Pty></Cdtr><CdtrAcct><Id><IBAN>GB29NWBK60161331926819</IBAN></Id></CdtrAcct></RltdPties>
+            <RltdAgts><CdtrAgt><FinInstnId><BICFI>BKAUATWW</BICFI></FinInstnId></CdtrAgt></RltdAgts>
+            <RmtInf><Ustrd>Cables</Ustrd><Strd><CdtrRefInf><Ref>RF18539007547034</Ref></CdtrRefInf></Strd></RmtInf>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+func TestParseBankStatement(t *testing.T) {
+	doc, err := ParseBankStatement(strings.NewReader(camt053V02Doc))
+	if err != nil {
+		t.Fatal("Expected ParseBankStatement return nil", "got", err)
+	}
+	if doc.Version != Camt053V02 || doc.MsgID != "STMT-2017-06-12" || len(doc.Statements) != 1 {
+		t.Fatal("Expected one camt.053.001.02 statement", "got", doc)
+	}
+	stmt := doc.Statements[0]
+	if stmt.AccountIBAN != "FR1420041010050500013M02606" || stmt.AccountBIC != "BKAUATWW" || stmt.ElectronicSeqNo != "112" {
+		t.Error("Expected statement account", "got", stmt.AccountIBAN, stmt.AccountBIC, stmt.ElectronicSeqNo)
+	}
+	if b := stmt.Balance(BalanceOpening); b == nil || b.Amount.Amount.Cmp(eur("1000")) != 0 || b.Date != "2017-06-11" {
+		t.Error("Expected opening balance 1000", "got", b)
+	}
+	if b := stmt.Balance(BalanceClosing); b == nil || b.Amount.Amount.Cmp(eur("1020.5")) != 0 {
+		t.Error("Expected closing balance 1020.5", "got", b)
+	}
+	if len(stmt.Entries) != 2 {
+		t.Fatal("Expected 2 entries", "got", len(stmt.Entries))
+	}
+	entry := stmt.Entries[0]
+	if entry.Status != "BOOK" || entry.BookingDate != "2017-06-11" || entry.BankTransactionCode.SubFamily != "ESDD" || len(entry.Details) != 2 {
+		t.Fatal("Expected booked ESDD entry with 2 details", "got", entry)
+	}
+	d := entry.Details[0]
+	if d.EndToEndID != "E2E-1" || d.MandateID != "MNDT-1" || d.PaymentInfoID != "PMT" || d.Amount.Amount.Cmp(eur("10")) != 0 || d.CreditDebit != Credit {
+		t.Error("Expected E2E-1 details", "got", d)
+	}
+	if c := d.Counterparty(); c.Name != "Debtor" || c.IBAN != "GB29NWBK60161331926819" || c.BIC != "BFAUAUWA" {
+		t.Error("Expected debtor as counterparty", "got", c)
+	}
+	if d.CreditorSchemeID != "DE98ZZZ09999999999" || len(d.RemittanceInfo) != 1 || d.RemittanceInfo[0] != "Invoice" {
+		t.Error("Expected creditor scheme ID and remittance information", "got", d.CreditorSchemeID, d.RemittanceInfo)
+	}
+	returned := stmt.Entries[1].Details[0]
+	if !returned.Returned() || returned.ReturnReason != "AC04" || returned.Amount.Amount.Cmp(eur("10")) != 0 || returned.CreditDebit != Debit {
+		t.Error("Expected returned E2E-0 details", "got", returned)
+	}
+
+	doc, err = ParseBankStatement(strings.NewReader(camt053V08Doc))
+	if err != nil {
+		t.Fatal("Expected ParseBankStatement return nil", "got", err)
+	}
+	stmt = doc.Statements[0]
+	if doc.Version != Camt053V08 || stmt.AccountBIC != "BKAUATWW" || stmt.Balance(BalanceClosing).CreditDebit != Debit {
+		t.Error("Expected camt.053.001.08 statement", "got", doc)
+	}
+	entry = stmt.Entries[0]
+	if entry.Status != "BOOK" || entry.BookingDate != "2023-11-19T10:00:00+01:00" {
+		t.Error("Expected booked entry", "got", entry.Status, entry.BookingDate)
+	}
+	d = entry.Details[0]
+	if c := d.Counterparty(); c.Name != "DEF Electronics" || c.IBAN != "GB29NWBK60161331926819" || c.BIC != "BKAUATWW" {
+		t.Error("Expected creditor as counterparty", "got", c)
+	}
+	if d.Amount.Amount.Cmp(eur("70")) != 0 || d.CreditorReference != "RF18539007547034" {
+		t.Error("Expected amount and creditor reference", "got", d.Amount, d.CreditorReference)
+	}
+
+	if _, err := ParseBankStatement(strings.NewReader(`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.03"/>`)); err == nil {
+		t.Error("Expected ParseBankStatement return an error for unsupported version")
+	}
+}
+func TestParseBankNotification(t *testing.T) {
+	var sepaDoc = &DirectDebit{}
+	if err := sepaDoc.InitDoc("MSGID", "PMT", "2017-06-07T14:39:33", "2017-06-11", "Emitter Name", "FR1420041010050500013M02606", "BKAUATWW", "DE98ZZZ09999999999", "DE", "some street", "some city"); err != nil {
+		t.Fatal("Expected InitDoc return nil", "got", err)
+	}
+	for _, id := range []string{"E2E-1", "E2E-2"} {
+		if err := sepaDoc.AddTransaction(id, eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-"+id, "2017-01-01", SequenceFirst, ""); err != nil {
+			t.Error("Expected AddTransaction return nil", "got", err)
+		}
+	}
+
+	var doc = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.054.001.04">
+  <BkToCstmrDbtCdtNtfctn>
+    <GrpHdr><MsgId>NTFCTN-1</MsgId><CreDtTm>2017-06-11T18:00:00</CreDtTm></GrpHdr>
+    <Ntfctn>
+      <Id>NTFCTN-1-1</Id>
+      <CreDtTm>2017-06-11T18:00:00</CreDtTm>
+      <Acct><Id><IBAN>FR1420041010050500013M02606</IBAN></Id></Acct>
+      <Ntry>
+        <Amt Ccy="EUR">20.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts>BOOK</Sts>
+        <BookgDt><Dt>2017-06-11</Dt></BookgDt><ValDt><Dt>2017-06-11</Dt></ValDt>
+        <NtryDtls>
+          <Btch><MsgId>MSGID</MsgId><PmtInfId>PMT</PmtInfId><NbOfTxs>2</NbOfTxs><TtlAmt Ccy="EUR">20.00</TtlAmt><CdtDbtInd>CRDT</CdtDbtInd></Btch>
+          <TxDtls><Refs><EndToEndId>E2E-1</EndToEndId><MndtId>MNDT-E2E-1</MndtId></Refs><Amt Ccy="EUR">10.00</Amt></TxDtls>
+          <TxDtls><Refs><EndToEndId>E2E-2</EndToEndId><MndtId>MNDT-E2E-2</MndtId></Refs><Amt Ccy="EUR">10.00</Amt></TxDtls>
+        </NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">5.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts>BOOK</Sts>
+        <BookgDt><Dt>2017-06-11</Dt></BookgDt>
+        <AcctSvcrRef>FEES</AcctSvcrRef>
+      </Ntry>
+    </Ntfctn>
+  </BkToCstmrDbtCdtNtfctn>
+</Document>`
+	notification, err := ParseBankNotification(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal("Expected ParseBankNotification return nil", "got", err)
+	}
+	if notification.Version != Camt054V04 || len(notification.Notifications) != 1 {
+		t.Fatal("Expected one camt.054.001.04 notification", "got", notification)
+	}
+	n := notification.Notifications[0]
+	if b := n.Entries[0].Batches; len(b) != 1 || b[0].TransactNo != 2 || b[0].TotalAmount.Amount.Cmp(eur("20")) != 0 {
+		t.Error("Expected batch of 2 transactions", "got", b)
+	}
+	transactions := n.Transactions()
+	if len(transactions) != 3 {
+		t.Fatal("Expected 3 transactions", "got", len(transactions))
+	}
+	for i, tx := range transactions[:2] {
+		built := sepaDoc.PaymentInfos[0].PaymentTransactions[i]
+		if tx.MsgID != sepaDoc.GroupHeaderMsgID || tx.PaymentInfoID != sepaDoc.PaymentInfos[0].PaymentInfoID || tx.EndToEndID != built.TransactIDe2e {
+			t.Error("Expected transaction linked to", built.TransactIDe2e, "got", tx.MsgID, tx.PaymentInfoID, tx.EndToEndID)
+		}
+		if tx.Amount.Amount.Cmp(eur("10")) != 0 || tx.CreditDebit != Credit || tx.Entry.BookingDate != "2017-06-11" {
+			t.Error("Expected booked credit of 10", "got", tx.Amount, tx.CreditDebit, tx.Entry.BookingDate)
+		}
+	}
+	if fees := transactions[2]; fees.Amount.Amount.Cmp(eur("5")) != 0 || fees.CreditDebit != Debit || fees.AccountServicerRef != "FEES" {
+		t.Error("Expected entry without details as a single transaction", "got", fees)
+	}
+
+	if _, err := ParseBankNotification(strings.NewReader(camt053V02Doc)); err == nil {
+		t.Error("Expected ParseBankNotification return an error for a statement")
+	}
+}
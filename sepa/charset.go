@@ -0,0 +1,106 @@
+package sepa
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// CharsetProfile is the character set a bank accepts in the text fields of a document
+type CharsetProfile int
+
+const (
+	// CharsetEPC is the Latin character set of the EPC implementation guidelines :
+	// a-z A-Z 0-9 / - ? : ( ) . , ' + and space
+	CharsetEPC CharsetProfile = iota
+	// CharsetDK is the character set of the German Deutsche Kreditwirtschaft : the EPC one with Ä Ö Ü ä ö ü ß & * $ %
+	CharsetDK
+)
+
+// CharsetMode tells what happens to the characters of a text field outside the character set of the profile
+type CharsetMode int
+
+const (
+	// CharsetPassThrough keeps the text fields untouched
+	CharsetPassThrough CharsetMode = iota
+	// CharsetReject returns an error naming the first character outside the character set
+	CharsetReject
+	// CharsetTransliterate replaces them : "ä" by "ae" unless the profile allows it, "é" by "e", "ł" by "l", "ş" by "s".
+	// A character without transliteration is replaced by ".".
+	CharsetTransliterate
+)
+
+// Charset is the character set of the text fields of a document : the names and postal addresses of the parties and
+// the remittance information of the transactions. The zero value passes them through untouched.
+type Charset struct {
+	Profile CharsetProfile
+	Mode    CharsetMode
+}
+
+// transliterations replaces the characters of the Latin-1 and Latin Extended-A blocks, which cover the names of
+// the SEPA countries and Turkey, and the usual punctuation by characters of the EPC character set
+var transliterations = transliterationTable(map[string]string{
+	"ÀÁÂÃÅĀĂĄ": "A", "àáâãåāăą": "a", "Ä": "Ae", "ä": "ae", "Æ": "AE", "æ": "ae",
+	"ÇĆĈĊČ": "C", "çćĉċč": "c", "ĎĐÐ": "D", "ďđð": "d",
+	"ÈÉÊËĒĔĖĘĚ": "E", "èéêëēĕėęě": "e", "ĜĞĠĢ": "G", "ĝğġģ": "g", "ĤĦ": "H", "ĥħ": "h",
+	"ÌÍÎÏĨĪĬĮİ": "I", "ìíîïĩīĭįı": "i", "Ĳ": "IJ", "ĳ": "ij", "Ĵ": "J", "ĵ": "j", "Ķ": "K", "ķĸ": "k",
+	"ĹĻĽĿŁ": "L", "ĺļľŀł": "l", "ÑŃŅŇŊ": "N", "ñńņňŉŋ": "n",
+	"ÒÓÔÕØŌŎŐ": "O", "òóôõøōŏő": "o", "Ö": "Oe", "ö": "oe", "Œ": "OE", "œ": "oe",
+	"ŔŖŘ": "R", "ŕŗř": "r", "ŚŜŞŠ": "S", "śŝşš": "s", "ß": "ss", "ŢŤŦ": "T", "ţťŧ": "t", "Þ": "Th", "þ": "th",
+	"ÙÚÛŨŪŬŮŰŲ": "U", "ùúûũūŭůűų": "u", "Ü": "Ue", "ü": "ue", "Ŵ": "W", "ŵ": "w",
+	"ÝŶŸ": "Y", "ýŷÿ": "y", "ŹŻŽ": "Z", "źżž": "z",
+	"&": "+", "_–—": "-", "\"‘’‚“”„«»`´": "'", "\t\n\r": " ", "€": "EUR",
+})
+
+func transliterationTable(m map[string]string) map[rune]string {
+	table := map[rune]string{}
+	for from, to := range m {
+		for _, r := range from {
+			table[r] = to
+		}
+	}
+	return table
+}
+
+// Sanitize returns the text in the character set of the profile, according to the mode
+func (c Charset) Sanitize(text string) (string, error) {
+	return c.sanitize("", text)
+}
+
+// allows tells whether the character is in the character set of the profile
+func (c Charset) allows(r rune) bool {
+	switch {
+	case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', strings.ContainsRune("/-?:().,'+ ", r):
+		return true
+	case c.Profile == CharsetDK:
+		return strings.ContainsRune("ÄÖÜäöüß&*$%", r)
+	}
+	return false
+}
+
+// sanitize returns the text of a field in the character set of the profile, the field is named in the error
+func (c Charset) sanitize(field string, text string) (string, error) {
+	if c.Mode == CharsetPassThrough {
+		return text, nil
+	}
+	var b strings.Builder
+	for _, r := range text {
+		if c.allows(r) {
+			b.WriteRune(r)
+			continue
+		}
+		if c.Mode == CharsetReject {
+			in := strconv.Quote(text)
+			if field != "" {
+				in = field + " " + in
+			}
+			return text, errors.New("invalid character " + strconv.QuoteRune(r) + " in " + in)
+		}
+		if t, ok := transliterations[r]; ok {
+			b.WriteString(t)
+		} else {
+			b.WriteString(".")
+		}
+	}
+	return b.String(), nil
+}
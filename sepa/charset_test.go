@@ -0,0 +1,95 @@
+package sepa
+
+import (
+	"testing"
+)
+
+func TestCharsetSanitize(t *testing.T) {
+	epc := Charset{Profile: CharsetEPC, Mode: CharsetTransliterate}
+	dk := Charset{Profile: CharsetDK, Mode: CharsetTransliterate}
+	for _, c := range []struct {
+		charset  Charset
+		text     string
+		expected string
+	}{
+		{epc, "Łukasz Wróblewski-Żak", "Lukasz Wroblewski-Zak"},
+		{epc, "Şükrü Öztürk, İzmir", "Suekrue Oeztuerk, Izmir"},
+		{epc, "Jürgen Müßig & Söhne", "Juergen Muessig + Soehne"},
+		{epc, "Rechnung №1 \"Café\"", "Rechnung .1 'Cafe'"},
+		{dk, "Jürgen Müßig & Söhne", "Jürgen Müßig & Söhne"},
+		{dk, "Zoë Ağaoğlu", "Zoe Agaoglu"},
+		{Charset{}, "Łukasz <Żak>", "Łukasz <Żak>"},
+	} {
+		if got, err := c.charset.Sanitize(c.text); err != nil || got != c.expected {
+			t.Error("Expected", c.expected, "got", got, err)
+		}
+	}
+
+	strict := Charset{Profile: CharsetEPC, Mode: CharsetReject}
+	if _, err := strict.Sanitize("Müller"); err == nil || err.Error() != `invalid character 'ü' in "Müller"` {
+		t.Error("Expected Sanitize reject ü", "got", err)
+	}
+	if got, err := strict.Sanitize("Invoice 2017/05-1 (paid)"); err != nil || got != "Invoice 2017/05-1 (paid)" {
+		t.Error("Expected Sanitize keep the EPC characters", "got", got, err)
+	}
+	strict.Profile = CharsetDK
+	if _, err := strict.Sanitize("Müller"); err != nil {
+		t.Error("Expected Sanitize accept ü in the DK character set", "got", err)
+	}
+}
+func TestCharsetCreditTransfer(t *testing.T) {
+	doc, err := NewCreditTransfer(CreditTransferConfig{MsgID: "MSGID", CreationDate: at("2017-05-01T22:45:03"),
+		ExecutionDate: day("2017-05-03"), Initiator: Party{Name: "Zakłady Mięsne Sp. z o.o."}, DebtorAccount: testEmitterAccount,
+		Charset: Charset{Profile: CharsetEPC, Mode: CharsetTransliterate}})
+	if err != nil {
+		t.Fatal("Expected NewCreditTransfer return nil", "got", err)
+	}
+	err = doc.AddTransfer(Transfer{ID: "F1", Amount: eur("10"), Currency: "EUR",
+		Creditor:        Party{Name: "Gülşen Çelik", Address: PostalAddress{Country: "TR", AddressLines: []string{"İstiklal Caddesi 5", "İstanbul"}}},
+		CreditorAccount: Account{IBAN: "GB29NWBK60161331926819"}, Description: "Faktura nr 5 – łącznie"})
+	if err != nil {
+		t.Fatal("Expected AddTransfer return nil", "got", err)
+	}
+	p := doc.PaymentInfos[0]
+	tx := p.PaymentTransactions[0]
+	if doc.GroupHeaderEmitterName != "Zaklady Miesne Sp. z o.o." || p.PaymentEmitterName != "Zaklady Miesne Sp. z o.o." ||
+		tx.TransactCreditorName != "Guelsen Celik" || tx.TransactCreditorPostalAddress.AddressLines[0] != "Istiklal Caddesi 5" ||
+		tx.TransactMotif.Unstructured != "Faktura nr 5 - lacznie" {
+		t.Error("Expected the text fields transliterated", "got", doc.GroupHeaderEmitterName, p.PaymentEmitterName, tx)
+	}
+
+	doc.SetCharset(Charset{Profile: CharsetEPC, Mode: CharsetReject})
+	err = doc.AddTransaction("F2", eur("10"), "EUR", "Łukasz", "GB29NWBK60161331926819", "", "Invoice")
+	if err == nil || err.Error() != `transaction F2 : creditor : invalid character 'Ł' in name "Łukasz"` {
+		t.Error("Expected AddTransaction reject the name of the creditor", "got", err)
+	}
+	err = doc.AddTransaction("F2", eur("10"), "EUR", "Lukasz", "GB29NWBK60161331926819", "", "Invoice €")
+	if err == nil || err.Error() != `transaction F2 : invalid character '€' in description "Invoice €"` {
+		t.Error("Expected AddTransaction reject the description", "got", err)
+	}
+	if doc.GroupHeaderTransactNo != 1 {
+		t.Error("Expected the rejected transactions not added", "got", doc.GroupHeaderTransactNo)
+	}
+}
+func TestCharsetDirectDebit(t *testing.T) {
+	doc, err := NewDirectDebit(DirectDebitConfig{MsgID: "MSGID", CreationDate: at("2017-06-07T14:39:33"),
+		CollectionDate: day("2017-06-11"), Initiator: Party{Name: "Stadtwerke Köln", Address: PostalAddress{Country: "DE", TownName: "Köln"}},
+		CreditorAccount: testEmitterAccount, CreditorSchemeID: "DE98ZZZ09999999999", Charset: Charset{Profile: CharsetDK, Mode: CharsetTransliterate}})
+	if err != nil {
+		t.Fatal("Expected NewDirectDebit return nil", "got", err)
+	}
+	if err := doc.AddTransaction("E2E", eur("10"), "EUR", "Kuşçu Öğüt", "GB29NWBK60161331926819", "", "Strom März", "MNDT", "2017-01-01", SequenceFirst, ""); err != nil {
+		t.Fatal("Expected AddTransaction return nil", "got", err)
+	}
+	p := doc.PaymentInfos[0]
+	tx := p.PaymentTransactions[0]
+	if p.PaymentEmitterName != "Stadtwerke Köln" || tx.TransactDebtorName != "Kuscu Ögüt" || tx.TransactMotif.Unstructured != "Strom März" {
+		t.Error("Expected the text fields in the DK character set", "got", p.PaymentEmitterName, tx)
+	}
+
+	_, err = NewDirectDebit(DirectDebitConfig{MsgID: "MSGID", CollectionDate: day("2017-06-11"), Initiator: Party{Name: "Zoë"},
+		CreditorAccount: testEmitterAccount, CreditorSchemeID: "DE98ZZZ09999999999", Charset: Charset{Profile: CharsetDK, Mode: CharsetReject}})
+	if err == nil || err.Error() != `initiator : invalid character 'ë' in name "Zoë"` {
+		t.Error("Expected NewDirectDebit reject the name of the initiator", "got", err)
+	}
+}
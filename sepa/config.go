@@ -0,0 +1,182 @@
+package sepa
+
+import (
+	"errors"
+	"time"
+)
+
+// CreditTransferConfig holds everything NewCreditTransfer needs, the zero value of an optional field selects its default
+type CreditTransferConfig struct {
+	// Version is the schema version, Pain001V03 by default
+	Version string
+	// MsgID identifies the message, mandatory
+	MsgID string
+	// PaymentInfoID identifies the first payment information group, MsgID by default
+	PaymentInfoID string
+	// CreationDate is written as CreDtTm with the UTC offset of its location, the time of Now by default
+	CreationDate time.Time
+	// Now is the clock CreationDate defaults to, time.Now by default
+	Now func() time.Time
+	// ExecutionDate is the requested execution date of the first group, mandatory
+	ExecutionDate Date
+	// Initiator is the initiating party, its name is mandatory
+	Initiator Party
+	// Debtor is the debtor of the first group, Initiator by default
+	Debtor Party
+	// DebtorAccount is the account the first group is paid from, its IBAN is mandatory
+	DebtorAccount Account
+	// DebtorAgent is the bank of DebtorAccount, NotProvided by default
+	DebtorAgent Agent
+	// Charset checks or transliterates the text fields, they are passed through by default
+	Charset Charset
+	// Truncate cuts the text fields longer than their length at a character boundary instead of returning an error.
+	// Identifiers are never cut.
+	Truncate bool
+}
+
+// withDefaults returns the config with the defaults of its empty optional fields
+func (c CreditTransferConfig) withDefaults() CreditTransferConfig {
+	if c.Version == "" {
+		c.Version = Pain001V03
+	}
+	if c.PaymentInfoID == "" {
+		c.PaymentInfoID = c.MsgID
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+	if c.CreationDate.IsZero() {
+		c.CreationDate = c.Now().Truncate(time.Second)
+	}
+	if c.Debtor.Name == "" {
+		c.Debtor = c.Initiator
+	}
+	c.DebtorAccount = c.DebtorAccount.normalized()
+	c.DebtorAgent = c.DebtorAgent.orNotProvided()
+	return c
+}
+
+// validate returns an error for the first missing or invalid field
+func (c CreditTransferConfig) validate() error {
+	if err := checkVersion(c.Version, Pain001V03, Pain001V09); err != nil {
+		return err
+	}
+	if c.MsgID == "" {
+		return errors.New("missing message ID")
+	}
+	if err := checkDate("execution date", c.ExecutionDate); err != nil {
+		return err
+	}
+	if err := c.Initiator.Validate(); err != nil {
+		return errors.New("initiator : " + err.Error())
+	}
+	if err := c.Debtor.Validate(); err != nil {
+		return errors.New("debtor : " + err.Error())
+	}
+	if err := c.DebtorAccount.Validate(); err != nil {
+		return errors.New("debtor account : " + err.Error())
+	}
+	if err := c.DebtorAgent.Validate(); err != nil {
+		return errors.New("debtor agent : " + err.Error())
+	}
+	return nil
+}
+
+// DirectDebitConfig holds everything NewDirectDebit needs, the zero value of an optional field selects its default
+type DirectDebitConfig struct {
+	// Version is the schema version, Pain008DKV02 by default
+	Version string
+	// MsgID identifies the message, mandatory
+	MsgID string
+	// PaymentInfoID is the base of the PmtInfId of every group, MsgID by default
+	PaymentInfoID string
+	// CreationDate is written as CreDtTm with the UTC offset of its location, the time of Now by default
+	CreationDate time.Time
+	// Now is the clock CreationDate defaults to, time.Now by default
+	Now func() time.Time
+	// CollectionDate is the collection date of transactions added without one, mandatory
+	CollectionDate Date
+	// Initiator is the initiating party, its name is mandatory
+	Initiator Party
+	// Creditor is the creditor of every group, Initiator by default.
+	// Pain008DKV02 has no creditor identification, the default leaves out the one of Initiator and any other is an error.
+	Creditor Party
+	// CreditorAccount is the account the debits are collected to, its IBAN is mandatory
+	CreditorAccount Account
+	// CreditorAgent is the bank of CreditorAccount, NotProvided by default
+	CreditorAgent Agent
+	// CreditorSchemeID is the SEPA creditor identifier, mandatory
+	CreditorSchemeID string
+	// LocalInstrument is CORE, COR1 or B2B, CORE by default
+	LocalInstrument string
+	// Charset checks or transliterates the text fields, they are passed through by default
+	Charset Charset
+	// Truncate cuts the text fields longer than their length at a character boundary instead of returning an error.
+	// Identifiers are never cut.
+	Truncate bool
+}
+
+// withDefaults returns the config with the defaults of its empty optional fields
+func (c DirectDebitConfig) withDefaults() DirectDebitConfig {
+	if c.Version == "" {
+		c.Version = Pain008DKV02
+	}
+	if c.PaymentInfoID == "" {
+		c.PaymentInfoID = c.MsgID
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+	if c.CreationDate.IsZero() {
+		c.CreationDate = c.Now().Truncate(time.Second)
+	}
+	if c.Creditor.Name == "" {
+		c.Creditor = c.Initiator
+		if c.Version == Pain008DKV02 {
+			c.Creditor.ID = PartyID{}
+		}
+	}
+	c.CreditorAccount = c.CreditorAccount.normalized()
+	c.CreditorAgent = c.CreditorAgent.orNotProvided()
+	if c.LocalInstrument == "" {
+		c.LocalInstrument = "CORE"
+	}
+	return c
+}
+
+// validate returns an error for the first missing or invalid field
+func (c DirectDebitConfig) validate() error {
+	if err := checkVersion(c.Version, Pain008DKV02, Pain008V02, Pain008V08); err != nil {
+		return err
+	}
+	if c.MsgID == "" {
+		return errors.New("missing message ID")
+	}
+	if err := checkDate("collection date", c.CollectionDate); err != nil {
+		return err
+	}
+	if err := c.Initiator.Validate(); err != nil {
+		return errors.New("initiator : " + err.Error())
+	}
+	if err := c.Creditor.Validate(); err != nil {
+		return errors.New("creditor : " + err.Error())
+	}
+	if c.Version == Pain008DKV02 && !c.Creditor.ID.isEmpty() {
+		return errors.New("creditor : identification not allowed by " + Pain008DKV02)
+	}
+	if err := c.CreditorAccount.Validate(); err != nil {
+		return errors.New("creditor account : " + err.Error())
+	}
+	if err := c.CreditorAgent.Validate(); err != nil {
+		return errors.New("creditor agent : " + err.Error())
+	}
+	if c.CreditorSchemeID == "" {
+		return errors.New("missing creditor scheme ID")
+	}
+	switch c.LocalInstrument {
+	case "CORE", "COR1", "B2B":
+	default:
+		return errors.New("invalid local instrument")
+	}
+	return nil
+}
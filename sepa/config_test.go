@@ -0,0 +1,136 @@
+package sepa
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testEmitter = Party{
+		Name:    "Emitter Name",
+		Address: PostalAddress{Country: "DE", AddressLines: []string{"some street", "some city"}},
+	}
+	testEmitterAccount = Account{IBAN: "FR14 2004 1010 0505 0001 3M02 606"}
+	testEmitterAgent   = Agent{BIC: "BKAUATWW"}
+)
+
+func TestNewCreditTransfer(t *testing.T) {
+	doc, err := NewCreditTransfer(CreditTransferConfig{
+		MsgID:         "MSGID",
+		PaymentInfoID: "PMT",
+		CreationDate:  at("2017-06-07T14:39:33"),
+		ExecutionDate: day("2017-06-11"),
+		Initiator:     testEmitter,
+		DebtorAccount: testEmitterAccount,
+		DebtorAgent:   testEmitterAgent,
+	})
+	if err != nil {
+		t.Fatal("Expected NewCreditTransfer return nil", "got", err)
+	}
+	old := &CreditTransfer{}
+	if err := old.InitDoc("MSGID", "PMT", "2017-06-07T14:39:33Z", "2017-06-11", "Emitter Name", "FR1420041010050500013M02606", "BKAUATWW", "DE", "some street", "some city"); err != nil {
+		t.Fatal("Expected InitDoc return nil", "got", err)
+	}
+	for _, d := range []*CreditTransfer{doc, old} {
+		if err := d.AddTransaction("E2E-1", eur("10"), "EUR", "Creditor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice"); err != nil {
+			t.Fatal("Expected AddTransaction return nil", "got", err)
+		}
+	}
+	str, _ := doc.Serialize()
+	oldStr, _ := old.Serialize()
+	if !bytes.Equal(str, oldStr) {
+		t.Error("Expected", string(oldStr), "got", string(str))
+	}
+
+	// defaults
+	doc, err = NewCreditTransfer(CreditTransferConfig{MsgID: "MSGID", ExecutionDate: day("2017-06-11"), Initiator: testEmitter,
+		Debtor: Party{Name: "Debtor Name"}, DebtorAccount: testEmitterAccount})
+	if err != nil {
+		t.Fatal("Expected NewCreditTransfer return nil", "got", err)
+	}
+	if doc.Version() != Pain001V03 || doc.PaymentInfos[0].PaymentInfoID != "MSGID" || doc.GroupHeaderCreateDate.IsZero() {
+		t.Error("Expected default version, payment info ID and creation date", "got", doc.Version(), doc.PaymentInfos[0].PaymentInfoID, doc.GroupHeaderCreateDate)
+	}
+	if doc.GroupHeaderEmitterName != "Emitter Name" || doc.PaymentInfos[0].PaymentEmitterName != "Debtor Name" {
+		t.Error("Expected initiator and debtor names", "got", doc.GroupHeaderEmitterName, doc.PaymentInfos[0].PaymentEmitterName)
+	}
+
+	// clock
+	paris := time.FixedZone("CEST", 2*60*60)
+	doc, err = NewCreditTransfer(CreditTransferConfig{MsgID: "MSGID", ExecutionDate: day("2017-06-11"), Initiator: testEmitter,
+		DebtorAccount: testEmitterAccount, Now: func() time.Time { return time.Date(2017, 6, 7, 14, 39, 33, 123456789, paris) }})
+	if err != nil {
+		t.Fatal("Expected NewCreditTransfer return nil", "got", err)
+	}
+	str, _ = doc.Serialize()
+	if !strings.Contains(string(str), "<CreDtTm>2017-06-07T14:39:33+02:00</CreDtTm>") {
+		t.Error("Expected the creation date of the clock with its offset", "got", string(str))
+	}
+
+	TTest := []struct {
+		name string
+		c    CreditTransferConfig
+	}{
+		{"missing message ID", CreditTransferConfig{ExecutionDate: day("2017-06-11"), Initiator: testEmitter, DebtorAccount: testEmitterAccount}},
+		{"bad version", CreditTransferConfig{Version: Pain008V02, MsgID: "MSGID", ExecutionDate: day("2017-06-11"), Initiator: testEmitter, DebtorAccount: testEmitterAccount}},
+		{"invalid execution date", CreditTransferConfig{MsgID: "MSGID", ExecutionDate: Date{2017, time.February, 30}, Initiator: testEmitter, DebtorAccount: testEmitterAccount}},
+		{"missing execution date", CreditTransferConfig{MsgID: "MSGID", Initiator: testEmitter, DebtorAccount: testEmitterAccount}},
+		{"missing initiator", CreditTransferConfig{MsgID: "MSGID", ExecutionDate: day("2017-06-11"), DebtorAccount: testEmitterAccount}},
+		{"name given as IBAN", CreditTransferConfig{MsgID: "MSGID", ExecutionDate: day("2017-06-11"), Initiator: testEmitter, DebtorAccount: Account{IBAN: "Emitter Name"}}},
+	}
+	for _, test := range TTest {
+		if _, err := NewCreditTransfer(test.c); err == nil {
+			t.Error("Expected NewCreditTransfer return an error for", test.name)
+		}
+	}
+}
+func TestNewDirectDebit(t *testing.T) {
+	doc, err := NewDirectDebit(DirectDebitConfig{
+		Version:          Pain008V08,
+		MsgID:            "MSGID",
+		PaymentInfoID:    "PMT",
+		CreationDate:     at("2017-06-07T14:39:33"),
+		CollectionDate:   day("2017-06-11"),
+		Initiator:        testEmitter,
+		CreditorAccount:  testEmitterAccount,
+		CreditorAgent:    testEmitterAgent,
+		CreditorSchemeID: "DE98ZZZ09999999999",
+	})
+	if err != nil {
+		t.Fatal("Expected NewDirectDebit return nil", "got", err)
+	}
+	old := &DirectDebit{}
+	if err := old.InitDoc("MSGID", "PMT", "2017-06-07T14:39:33Z", "2017-06-11", "Emitter Name", "FR1420041010050500013M02606", "BKAUATWW", "DE98ZZZ09999999999", "DE", "some street", "some city"); err != nil {
+		t.Fatal("Expected InitDoc return nil", "got", err)
+	}
+	if err := old.SetVersion(Pain008V08); err != nil {
+		t.Fatal("Expected SetVersion return nil", "got", err)
+	}
+	for _, d := range []*DirectDebit{doc, old} {
+		if err := d.AddTransaction("E2E-1", eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-1", "2017-01-01", SequenceFirst, ""); err != nil {
+			t.Fatal("Expected AddTransaction return nil", "got", err)
+		}
+	}
+	str, _ := doc.Serialize()
+	oldStr, _ := old.Serialize()
+	if !bytes.Equal(str, oldStr) {
+		t.Error("Expected", string(oldStr), "got", string(str))
+	}
+
+	TTest := []struct {
+		name string
+		c    DirectDebitConfig
+	}{
+		{"missing creditor scheme ID", DirectDebitConfig{MsgID: "MSGID", CollectionDate: day("2017-06-11"), Initiator: testEmitter, CreditorAccount: testEmitterAccount}},
+		{"bad local instrument", DirectDebitConfig{MsgID: "MSGID", CollectionDate: day("2017-06-11"), Initiator: testEmitter, CreditorAccount: testEmitterAccount, CreditorSchemeID: "DE98ZZZ09999999999", LocalInstrument: "B2C"}},
+		{"bad IBAN", DirectDebitConfig{MsgID: "MSGID", CollectionDate: day("2017-06-11"), Initiator: testEmitter, CreditorAccount: Account{IBAN: "XX12345678901234567"}, CreditorSchemeID: "DE98ZZZ09999999999"}},
+		{"bad collection date", DirectDebitConfig{MsgID: "MSGID", CollectionDate: Date{2017, 13, 1}, Initiator: testEmitter, CreditorAccount: testEmitterAccount, CreditorSchemeID: "DE98ZZZ09999999999"}},
+	}
+	for _, test := range TTest {
+		if _, err := NewDirectDebit(test.c); err == nil {
+			t.Error("Expected NewDirectDebit return an error for", test.name)
+		}
+	}
+}
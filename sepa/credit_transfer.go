@@ -4,132 +4,435 @@ import (
 	"encoding/xml"
 	"errors"
 	"github.com/flofuenf/gosepa/lib"
-	"strings"
-	"time"
+	"io"
 )
 
 // CreditTransfer is the SEPA format for the document containing all credit transfers
 type CreditTransfer struct {
-	XMLName                     xml.Name            `xml:"Document"`
-	XMLXsiLoc                   string              `xml:"xsi:schemaLocation,attr"`
-	XMLNs                       string              `xml:"xmlns,attr"`
-	XMLXsi                      string              `xml:"xmlns:xsi,attr"`
-	GroupHeaderMsgID            string              `xml:"CstmrCdtTrfInitn>GrpHdr>MsgId"`
-	GroupHeaderCreateDate       string              `xml:"CstmrCdtTrfInitn>GrpHdr>CreDtTm"`
-	GroupHeaderTransactNo       int                 `xml:"CstmrCdtTrfInitn>GrpHdr>NbOfTxs"`
-	GroupHeaderCtrlSum          float64             `xml:"CstmrCdtTrfInitn>GrpHdr>CtrlSum"`
-	GroupHeaderEmitterName      string              `xml:"CstmrCdtTrfInitn>GrpHdr>InitgPty>Nm"`
-	PaymentInfoID               string              `xml:"CstmrCdtTrfInitn>PmtInf>PmtInfId"`
-	PaymentInfoMethod           string              `xml:"CstmrCdtTrfInitn>PmtInf>PmtMtd"`
-	PaymentBatch                string              `xml:"CstmrCdtTrfInitn>PmtInf>BtchBookg"`
-	PaymentInfoTransactNo       int                 `xml:"CstmrCdtTrfInitn>PmtInf>NbOfTxs"`
-	PaymentInfoCtrlSum          float64             `xml:"CstmrCdtTrfInitn>PmtInf>CtrlSum"`
-	PaymentTypeInfo             string              `xml:"CstmrCdtTrfInitn>PmtInf>PmtTpInf>SvcLvl>Cd"`
-	PaymentExecDate             string              `xml:"CstmrCdtTrfInitn>PmtInf>ReqdExctnDt"`
-	PaymentEmitterName          string              `xml:"CstmrCdtTrfInitn>PmtInf>Dbtr>Nm"`
-	PaymentEmitterPostalCountry string              `xml:"CstmrCdtTrfInitn>PmtInf>Dbtr>PstlAdr>Ctry"`
-	PaymentEmitterPostalAddress []string            `xml:"CstmrCdtTrfInitn>PmtInf>Dbtr>PstlAdr>AdrLine"`
-	PaymentEmitterDebitorID     string              `xml:"CstmrCdtTrfInitn>PmtInf>Dbtr>Id>OrgId"`
-	PaymentEmitterIBAN          string              `xml:"CstmrCdtTrfInitn>PmtInf>DbtrAcct>Id>IBAN"`
-	PaymentEmitterBIC           string              `xml:"CstmrCdtTrfInitn>PmtInf>DbtrAgt>FinInstnId>BIC"`
-	PaymentCharge               string              `xml:"CstmrCdtTrfInitn>PmtInf>ChrgBr"`
-	PaymentTransactions         []CreditTransaction `xml:"CstmrCdtTrfInitn>PmtInf>CdtTrfTxInf"`
+	XMLName                xml.Name            `xml:"Document"`
+	XMLXsiLoc              string              `xml:"xsi:schemaLocation,attr"`
+	XMLNs                  string              `xml:"xmlns,attr"`
+	XMLXsi                 string              `xml:"xmlns:xsi,attr"`
+	GroupHeaderMsgID       string              `xml:"CstmrCdtTrfInitn>GrpHdr>MsgId"`
+	GroupHeaderCreateDate  DateTime            `xml:"CstmrCdtTrfInitn>GrpHdr>CreDtTm"`
+	GroupHeaderTransactNo  int                 `xml:"CstmrCdtTrfInitn>GrpHdr>NbOfTxs"`
+	GroupHeaderCtrlSum     Amount              `xml:"CstmrCdtTrfInitn>GrpHdr>CtrlSum"`
+	GroupHeaderEmitterName string              `xml:"CstmrCdtTrfInitn>GrpHdr>InitgPty>Nm"`
+	GroupHeaderEmitterID   *PartyID            `xml:"CstmrCdtTrfInitn>GrpHdr>InitgPty>Id"`
+	PaymentInfos           []CreditPaymentInfo `xml:"CstmrCdtTrfInitn>PmtInf"`
+
+	// charset and truncate apply to the text fields added to the document
+	charset  Charset
+	truncate bool
+}
+
+// CreditPaymentInfo is a payment information group (PmtInf) sharing execution date, debtor and debtor account
+type CreditPaymentInfo struct {
+	PaymentInfoID               string              `xml:"PmtInfId"`
+	PaymentInfoMethod           string              `xml:"PmtMtd"`
+	PaymentBatch                string              `xml:"BtchBookg"`
+	PaymentInfoTransactNo       int                 `xml:"NbOfTxs"`
+	PaymentInfoCtrlSum          Amount              `xml:"CtrlSum"`
+	PaymentTypeInfo             string              `xml:"PmtTpInf>SvcLvl>Cd"`
+	PaymentExecDate             Date                `xml:"ReqdExctnDt"`
+	PaymentEmitterName          string              `xml:"Dbtr>Nm"`
+	PaymentEmitterPostalAddress *PostalAddress      `xml:"Dbtr>PstlAdr"`
+	PaymentEmitterDebitorID     *PartyID            `xml:"Dbtr>Id"`
+	PaymentEmitterIBAN          string              `xml:"DbtrAcct>Id>IBAN"`
+	PaymentEmitterCurrency      string              `xml:"DbtrAcct>Ccy,omitempty"`
+	PaymentEmitterAgent         Agent               `xml:"DbtrAgt"`
+	PaymentCharge               string              `xml:"ChrgBr"`
+	PaymentTransactions         []CreditTransaction `xml:"CdtTrfTxInf"`
 }
 
 // CreditTransaction is the transfer SEPA format
 type CreditTransaction struct {
-	TransactID           string  `xml:"PmtId>InstrId"`
-	TransactIDe2e        string  `xml:"PmtId>EndToEndId"`
-	TransactAmount       TAmount `xml:"Amt>InstdAmt"`
-	TransactCreditorBic  string  `xml:"CdtrAgt>FinInstnId>BIC"`
-	TransactCreditorName string  `xml:"Cdtr>Nm"`
-	TransactCreditorIBAN string  `xml:"CdtrAcct>Id>IBAN"`
-	TransactMotif        string  `xml:"RmtInf>Ustrd"`
+	TransactID                    string          `xml:"PmtId>InstrId"`
+	TransactIDe2e                 string          `xml:"PmtId>EndToEndId"`
+	TransactAmount                TAmount         `xml:"Amt>InstdAmt"`
+	TransactCreditorAgent         Agent           `xml:"CdtrAgt"`
+	TransactCreditorName          string          `xml:"Cdtr>Nm"`
+	TransactCreditorPostalAddress *PostalAddress  `xml:"Cdtr>PstlAdr"`
+	TransactCreditorID            *PartyID        `xml:"Cdtr>Id"`
+	TransactCreditorIBAN          string          `xml:"CdtrAcct>Id>IBAN"`
+	TransactCreditorCurrency      string          `xml:"CdtrAcct>Ccy,omitempty"`
+	TransactMotif                 *RemittanceInfo `xml:"RmtInf"`
+
+	// TransactStatus is the status reported by the bank, see ApplyStatusReport
+	TransactStatus *TransactionStatus `xml:"-"`
+}
+
+// Debtor returns the debtor of the group
+func (p *CreditPaymentInfo) Debtor() Party {
+	debtor := Party{Name: p.PaymentEmitterName}
+	if p.PaymentEmitterPostalAddress != nil {
+		debtor.Address = *p.PaymentEmitterPostalAddress
+	}
+	if p.PaymentEmitterDebitorID != nil {
+		debtor.ID = *p.PaymentEmitterDebitorID
+	}
+	return debtor
+}
+
+// DebtorAccount returns the account the group is paid from
+func (p *CreditPaymentInfo) DebtorAccount() Account {
+	return Account{IBAN: p.PaymentEmitterIBAN, Currency: p.PaymentEmitterCurrency}
+}
+
+// Creditor returns the creditor of the transaction
+func (t CreditTransaction) Creditor() Party {
+	p := Party{Name: t.TransactCreditorName}
+	if t.TransactCreditorPostalAddress != nil {
+		p.Address = *t.TransactCreditorPostalAddress
+	}
+	if t.TransactCreditorID != nil {
+		p.ID = *t.TransactCreditorID
+	}
+	return p
+}
+
+// CreditorAccount returns the account the transaction is paid to
+func (t CreditTransaction) CreditorAccount() Account {
+	return Account{IBAN: t.TransactCreditorIBAN, Currency: t.TransactCreditorCurrency}
+}
+
+// Transfer is a credit transfer to add to a document
+type Transfer struct {
+	// ID is written as InstrId and EndToEndId
+	ID              string
+	Amount          Amount
+	Currency        string
+	Creditor        Party
+	CreditorAccount Account
+	// CreditorAgent is left out when it is the zero value
+	CreditorAgent Agent
+	Description   string
 }
 
 // TAmount is the transaction amount with its currency
 type TAmount struct {
-	Amount   float64 `xml:",chardata"`
-	Currency string  `xml:"Ccy,attr"`
+	Amount   Amount `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+// MarshalXML writes the amount in the ActiveOrHistoricCurrencyAndAmount format : the fraction digits of its currency
+// and the Ccy attribute, "70000.00" for EUR
+func (a TAmount) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	amount, err := a.Amount.Rescale(CurrencyExponent(a.Currency))
+	if err != nil {
+		return err
+	}
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "Ccy"}, Value: a.Currency})
+	return e.EncodeElement(amount.String(), start)
 }
 
-// InitDoc fixes every constant in the document + emitter information
+// RemittanceInfo is the unstructured remittance information of a transaction, nil when it has no description
+type RemittanceInfo struct {
+	Unstructured string `xml:"Ustrd"`
+}
+
+// newRemittanceInfo returns the remittance information of a description, nil if it is empty
+func newRemittanceInfo(description string) *RemittanceInfo {
+	if description == "" {
+		return nil
+	}
+	return &RemittanceInfo{Unstructured: description}
+}
+
+// PostalAddress is a postal address, structured or given as address lines
+type PostalAddress struct {
+	StreetName     string   `xml:"StrtNm,omitempty"`
+	BuildingNumber string   `xml:"BldgNb,omitempty"`
+	PostCode       string   `xml:"PstCd,omitempty"`
+	TownName       string   `xml:"TwnNm,omitempty"`
+	Country        string   `xml:"Ctry,omitempty"`
+	AddressLines   []string `xml:"AdrLine"`
+}
+
+// NewCreditTransfer returns a document with a first payment information group, built from a validated config
+func NewCreditTransfer(c CreditTransferConfig) (*CreditTransfer, error) {
+	c = c.withDefaults()
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	doc := &CreditTransfer{charset: c.Charset, truncate: c.Truncate}
+	if err := doc.init(c.Version, c.MsgID, DateTimeOf(c.CreationDate), c.Initiator.Name); err != nil {
+		return nil, err
+	}
+	doc.GroupHeaderEmitterID = c.Initiator.ID.ref()
+	if err := doc.AddPaymentInfoFor(c.PaymentInfoID, c.ExecutionDate, c.Debtor, c.DebtorAccount, c.DebtorAgent); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// InitDoc fixes every constant in the document + emitter information of the first payment information group
+//
+// Deprecated: use NewCreditTransfer, its named fields can't be swapped by mistake.
 func (doc *CreditTransfer) InitDoc(msgID string, paymentInfoID string, creationDate string, executionDate string,
 	emitterName string, emitterIBAN string, emitterBIC string, countryCode string, street string, city string) error {
-	emitterIBAN = strings.Join(strings.Fields(emitterIBAN), "")
-	if _, err := time.Parse("2006-01-02T15:04:05", creationDate); err != nil {
+	created, err := ParseDateTime(creationDate)
+	if err != nil {
 		return err
 	}
-	if _, err := time.Parse("2006-01-02", executionDate); err != nil {
+	if err := doc.init(Pain001V03, msgID, created, emitterName); err != nil {
 		return err
 	}
-	if !lib.IsValid(emitterIBAN) {
-		return errors.New("invalid emitter IBAN")
-	}
-	doc.XMLXsiLoc = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03 pain.001.001.03.xsd"
-	doc.XMLNs = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"
-	doc.XMLXsi = "http://www.w3.org/2001/XMLSchema-instance"
-	doc.PaymentInfoMethod = "TRF" // always TRF (in old version DD???)
-	doc.PaymentTypeInfo = "SEPA"  // always SEPA
-	doc.PaymentCharge = "SLEV"    // always SLEV
-	doc.PaymentBatch = "true"     //always true??
-	doc.PaymentEmitterDebitorID = "DE79ZZZ00000628465"
+	return doc.AddPaymentInfo(paymentInfoID, executionDate, emitterName, emitterIBAN, emitterBIC, countryCode, street, city)
+}
+
+// init fixes every constant in the group header and removes the payment information groups
+func (doc *CreditTransfer) init(version string, msgID string, creationDate DateTime, initiatorName string) error {
+	if err := doc.SetVersion(version); err != nil {
+		return err
+	}
+	if err := checkID("message ID", msgID); err != nil {
+		return err
+	}
+	initiatorName, err := doc.text().name(initiatorName)
+	if err != nil {
+		return errors.New("initiator : " + err.Error())
+	}
+	doc.XMLXsi = xsiNamespace
 	doc.GroupHeaderMsgID = msgID
-	doc.PaymentInfoID = paymentInfoID
 	doc.GroupHeaderCreateDate = creationDate
-	doc.PaymentExecDate = executionDate
-	doc.GroupHeaderEmitterName = emitterName
-	doc.PaymentEmitterName = emitterName
-	doc.PaymentEmitterIBAN = emitterIBAN
-	doc.PaymentEmitterBIC = emitterBIC
-	doc.PaymentEmitterPostalCountry = countryCode
-	doc.PaymentEmitterPostalAddress = make([]string, 0)
-	doc.PaymentEmitterPostalAddress = append(doc.PaymentEmitterPostalAddress, street)
-	doc.PaymentEmitterPostalAddress = append(doc.PaymentEmitterPostalAddress, city)
+	doc.GroupHeaderEmitterName = initiatorName
+	doc.GroupHeaderEmitterID = nil
+	doc.GroupHeaderTransactNo = 0
+	doc.GroupHeaderCtrlSum = Amount{}
+	doc.PaymentInfos = nil
 	return nil
 }
 
-// AddTransaction adds a transfer transaction and adjust the transaction number and the sum control
-func (doc *CreditTransfer) AddTransaction(id string, amount float64, currency string, creditorName string,
-	creditorIBAN string, bic string, description string) error {
-	creditorIBAN = strings.Join(strings.Fields(creditorIBAN), "")
-	if !lib.IsValid(creditorIBAN) {
-		return errors.New("invalid creditor IBAN")
-	}
-	if lib.DecimalsNumber(amount) > 2 {
-		return errors.New("amount 2 decimals only")
-	}
-	doc.PaymentTransactions = append(doc.PaymentTransactions, CreditTransaction{
-		TransactID:           id,
-		TransactIDe2e:        id,
-		TransactMotif:        description,
-		TransactAmount:       TAmount{Amount: amount, Currency: currency},
-		TransactCreditorName: creditorName,
-		TransactCreditorIBAN: creditorIBAN,
-		TransactCreditorBic:  bic,
-	})
-	doc.GroupHeaderTransactNo++
-	doc.PaymentInfoTransactNo++
+// AddPaymentInfo adds a payment information group with its own execution date, debtor and debtor account.
+// Subsequent calls to AddTransaction fill this group.
+func (doc *CreditTransfer) AddPaymentInfo(paymentInfoID string, executionDate string, emitterName string,
+	emitterIBAN string, emitterBIC string, countryCode string, street string, city string) error {
+	date, err := ParseDate(executionDate)
+	if err != nil {
+		return err
+	}
+	return doc.AddPaymentInfoFor(paymentInfoID, date,
+		Party{Name: emitterName, Address: PostalAddress{Country: countryCode, AddressLines: []string{street, city}}},
+		Account{IBAN: emitterIBAN}, Agent{BIC: emitterBIC})
+}
 
-	amountCents, err := lib.ToCents(amount)
+// AddPaymentInfoFor adds a payment information group with its own execution date, debtor, debtor account and agent.
+// A zero agent is written as NotProvided. Subsequent calls to AddTransaction fill this group.
+func (doc *CreditTransfer) AddPaymentInfoFor(paymentInfoID string, executionDate Date, debtor Party, account Account,
+	agent Agent) error {
+	account = account.normalized()
+	if err := checkDate("execution date", executionDate); err != nil {
+		return err
+	}
+	if err := checkID("payment info ID", paymentInfoID); err != nil {
+		return err
+	}
+	debtor, err := doc.text().party(debtor)
 	if err != nil {
-		return errors.New("in AddTransaction can't convert amount in cents")
+		return errors.New("debtor : " + err.Error())
+	}
+	if err := debtor.Validate(); err != nil {
+		return errors.New("debtor : " + err.Error())
+	}
+	if err := account.Validate(); err != nil {
+		return errors.New("debtor account : " + err.Error())
+	}
+	if err := agent.Validate(); err != nil {
+		return errors.New("debtor agent : " + err.Error())
 	}
-	cumulusCents, err := lib.ToCents(doc.GroupHeaderCtrlSum)
+	if doc.PaymentInfo(paymentInfoID) != nil {
+		return errors.New("duplicate payment info ID")
+	}
+	doc.PaymentInfos = append(doc.PaymentInfos, CreditPaymentInfo{
+		PaymentInfoID:               paymentInfoID,
+		PaymentInfoMethod:           "TRF",  // always TRF (in old version DD???)
+		PaymentTypeInfo:             "SEPA", // always SEPA
+		PaymentCharge:               "SLEV", // always SLEV
+		PaymentBatch:                "true", //always true??
+		PaymentEmitterDebitorID:     debtor.ID.ref(),
+		PaymentExecDate:             executionDate,
+		PaymentEmitterName:          debtor.Name,
+		PaymentEmitterIBAN:          account.IBAN,
+		PaymentEmitterCurrency:      account.Currency,
+		PaymentEmitterAgent:         agent.orNotProvided(),
+		PaymentEmitterPostalAddress: debtor.postalAddress(),
+	})
+	return nil
+}
+
+// SetVersion selects the schema version (Pain001V03 or Pain001V09) the document is written in
+func (doc *CreditTransfer) SetVersion(version string) error {
+	if err := checkVersion(version, Pain001V03, Pain001V09); err != nil {
+		return err
+	}
+	doc.XMLXsiLoc = schemaLocation(version)
+	doc.XMLNs = namespace(version)
+	return nil
+}
+
+// Version returns the schema version of the document
+func (doc *CreditTransfer) Version() string {
+	return versionOf(doc.XMLNs)
+}
+
+// SetCharset sets the character set of the text fields added from now on
+func (doc *CreditTransfer) SetCharset(c Charset) {
+	doc.charset = c
+}
+
+// SetTruncate tells whether the text fields added from now on are cut to their length instead of returning an error
+func (doc *CreditTransfer) SetTruncate(truncate bool) {
+	doc.truncate = truncate
+}
+
+// text returns the rules the text fields added to the document are checked against
+func (doc *CreditTransfer) text() textRules {
+	return newTextRules(doc.Version(), doc.charset, doc.truncate)
+}
+
+// PaymentInfo returns the payment information group with the given ID, nil if there is none
+func (doc *CreditTransfer) PaymentInfo(paymentInfoID string) *CreditPaymentInfo {
+	for i := range doc.PaymentInfos {
+		if doc.PaymentInfos[i].PaymentInfoID == paymentInfoID {
+			return &doc.PaymentInfos[i]
+		}
+	}
+	return nil
+}
+
+// AddTransaction adds a transfer transaction to the last payment information group
+// and adjust the transaction number and the sum control
+func (doc *CreditTransfer) AddTransaction(id string, amount Amount, currency string, creditorName string,
+	creditorIBAN string, bic string, description string) error {
+	return doc.AddTransfer(newTransfer(id, amount, currency, creditorName, creditorIBAN, bic, description))
+}
+
+// AddTransactionTo adds a transfer transaction to the payment information group with the given ID
+// and adjust the transaction number and the sum control
+func (doc *CreditTransfer) AddTransactionTo(paymentInfoID string, id string, amount Amount, currency string,
+	creditorName string, creditorIBAN string, bic string, description string) error {
+	return doc.AddTransferTo(paymentInfoID, newTransfer(id, amount, currency, creditorName, creditorIBAN, bic, description))
+}
+
+// newTransfer returns the transfer of the positional arguments of AddTransaction
+func newTransfer(id string, amount Amount, currency string, creditorName string, creditorIBAN string, bic string,
+	description string) Transfer {
+	return Transfer{
+		ID:              id,
+		Amount:          amount,
+		Currency:        currency,
+		Creditor:        Party{Name: creditorName},
+		CreditorAccount: Account{IBAN: creditorIBAN},
+		CreditorAgent:   Agent{BIC: bic},
+		Description:     description,
+	}
+}
+
+// AddTransfer adds a transfer to the last payment information group
+// and adjust the transaction number and the sum control
+func (doc *CreditTransfer) AddTransfer(t Transfer) error {
+	if len(doc.PaymentInfos) == 0 {
+		return errors.New("no payment info, call NewCreditTransfer or AddPaymentInfo first")
+	}
+	return doc.addTransfer(&doc.PaymentInfos[len(doc.PaymentInfos)-1], t)
+}
+
+// AddTransferTo adds a transfer to the payment information group with the given ID
+// and adjust the transaction number and the sum control
+func (doc *CreditTransfer) AddTransferTo(paymentInfoID string, t Transfer) error {
+	pmtInf := doc.PaymentInfo(paymentInfoID)
+	if pmtInf == nil {
+		return errors.New("unknown payment info ID")
+	}
+	return doc.addTransfer(pmtInf, t)
+}
+
+func (doc *CreditTransfer) addTransfer(pmtInf *CreditPaymentInfo, t Transfer) error {
+	transaction, err := newCreditTransaction(t, doc.text())
 	if err != nil {
-		return errors.New("in AddTransaction can't convert control sum in cents")
+		return err
 	}
+	amount := transaction.TransactAmount.Amount
 
-	cumulusEuro, err := lib.ToEuro(cumulusCents + amountCents)
+	pmtInfSum, err := pmtInf.PaymentInfoCtrlSum.Add(amount)
 	if err != nil {
-		return errors.New("in AddTransaction can't convert cumulus in euro")
+		return err
+	}
+	groupSum, err := doc.GroupHeaderCtrlSum.Add(amount)
+	if err != nil {
+		return err
 	}
 
-	doc.GroupHeaderCtrlSum = cumulusEuro
-	doc.PaymentInfoCtrlSum = cumulusEuro
+	pmtInf.PaymentTransactions = append(pmtInf.PaymentTransactions, transaction)
+	pmtInf.PaymentInfoTransactNo++
+	pmtInf.PaymentInfoCtrlSum = pmtInfSum
+	doc.GroupHeaderTransactNo++
+	doc.GroupHeaderCtrlSum = groupSum
 	return nil
 }
 
+// newCreditTransaction returns the transaction of a transfer checked by checkTransfer,
+// its errors name the transaction
+func newCreditTransaction(t Transfer, r textRules) (CreditTransaction, error) {
+	t, err := checkTransfer(t, r)
+	if err != nil {
+		return CreditTransaction{}, errors.New("transaction " + t.ID + " : " + err.Error())
+	}
+	return CreditTransaction{
+		TransactID:                    t.ID,
+		TransactIDe2e:                 t.ID,
+		TransactMotif:                 newRemittanceInfo(t.Description),
+		TransactAmount:                TAmount{Amount: t.Amount, Currency: t.Currency},
+		TransactCreditorName:          t.Creditor.Name,
+		TransactCreditorPostalAddress: t.Creditor.postalAddress(),
+		TransactCreditorID:            t.Creditor.ID.ref(),
+		TransactCreditorIBAN:          t.CreditorAccount.IBAN,
+		TransactCreditorCurrency:      t.CreditorAccount.Currency,
+		TransactCreditorAgent:         t.CreditorAgent,
+	}, nil
+}
+
+// checkTransfer validates a transfer and returns it with its amount in the fraction digits of its currency,
+// its text fields in the character set and within their lengths and its account normalized
+func checkTransfer(t Transfer, r textRules) (Transfer, error) {
+	if err := checkID("end to end ID", t.ID); err != nil {
+		return t, err
+	}
+	var err error
+	if t.Creditor, err = r.party(t.Creditor); err != nil {
+		return t, errors.New("creditor : " + err.Error())
+	}
+	if t.Description, err = r.text("description", t.Description, max140Text); err != nil {
+		return t, err
+	}
+	t.CreditorAccount = t.CreditorAccount.normalized()
+	if err := t.CreditorAccount.Validate(); err != nil {
+		return t, errors.New("creditor account : " + err.Error())
+	}
+	if err := t.Creditor.Validate(); err != nil {
+		return t, errors.New("creditor : " + err.Error())
+	}
+	if err := t.CreditorAgent.Validate(); err != nil {
+		return t, errors.New("creditor agent : " + err.Error())
+	}
+	if t.Amount, err = checkAmount(t.Amount, t.Currency); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+// creditTransfer has the pain.001.001.03 layout of CreditTransfer without its MarshalXML method
+type creditTransfer CreditTransfer
+
+// MarshalXML writes the document in the layout of its schema version
+func (doc *CreditTransfer) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	if doc.Version() == Pain001V09 {
+		return e.Encode(newCreditTransferV09(doc))
+	}
+	return e.Encode((*creditTransfer)(doc))
+}
+
 // Serialize returns the xml document in byte stream
 func (doc *CreditTransfer) Serialize() ([]byte, error) {
 	return lib.Serialize(doc)
@@ -139,3 +442,13 @@ func (doc *CreditTransfer) Serialize() ([]byte, error) {
 func (doc *CreditTransfer) PrettySerialize() ([]byte, error) {
 	return lib.PrettySerialize(doc)
 }
+
+// SerializeTo writes the xml document to w, see also CreditTransferEncoder to write it transaction by transaction
+func (doc *CreditTransfer) SerializeTo(w io.Writer) error {
+	return lib.SerializeTo(w, doc)
+}
+
+// PrettySerializeTo writes the indented xml document to w
+func (doc *CreditTransfer) PrettySerializeTo(w io.Writer) error {
+	return lib.PrettySerializeTo(w, doc)
+}
@@ -0,0 +1,163 @@
+package sepa
+
+import (
+	"encoding/xml"
+)
+
+// creditTransferV09 is the pain.001.001.09 layout of a CreditTransfer
+type creditTransferV09 struct {
+	XMLName                xml.Name               `xml:"Document"`
+	XMLXsiLoc              string                 `xml:"xsi:schemaLocation,attr"`
+	XMLNs                  string                 `xml:"xmlns,attr"`
+	XMLXsi                 string                 `xml:"xmlns:xsi,attr"`
+	GroupHeaderMsgID       string                 `xml:"CstmrCdtTrfInitn>GrpHdr>MsgId"`
+	GroupHeaderCreateDate  DateTime               `xml:"CstmrCdtTrfInitn>GrpHdr>CreDtTm"`
+	GroupHeaderTransactNo  int                    `xml:"CstmrCdtTrfInitn>GrpHdr>NbOfTxs"`
+	GroupHeaderCtrlSum     Amount                 `xml:"CstmrCdtTrfInitn>GrpHdr>CtrlSum"`
+	GroupHeaderEmitterName string                 `xml:"CstmrCdtTrfInitn>GrpHdr>InitgPty>Nm"`
+	GroupHeaderEmitterID   *partyIDXML            `xml:"CstmrCdtTrfInitn>GrpHdr>InitgPty>Id"`
+	PaymentInfos           []creditPaymentInfoV09 `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+// creditPaymentInfoV09 is the pain.001.001.09 layout of a CreditPaymentInfo
+type creditPaymentInfoV09 struct {
+	PaymentInfoID               string                 `xml:"PmtInfId"`
+	PaymentInfoMethod           string                 `xml:"PmtMtd"`
+	PaymentBatch                string                 `xml:"BtchBookg"`
+	PaymentInfoTransactNo       int                    `xml:"NbOfTxs"`
+	PaymentInfoCtrlSum          Amount                 `xml:"CtrlSum"`
+	PaymentTypeInfo             string                 `xml:"PmtTpInf>SvcLvl>Cd"`
+	PaymentExecDate             Date                   `xml:"ReqdExctnDt>Dt"`
+	PaymentEmitterName          string                 `xml:"Dbtr>Nm"`
+	PaymentEmitterPostalAddress *postalAddress24       `xml:"Dbtr>PstlAdr"`
+	PaymentEmitterDebitorID     *partyIDXML            `xml:"Dbtr>Id"`
+	PaymentEmitterIBAN          string                 `xml:"DbtrAcct>Id>IBAN"`
+	PaymentEmitterCurrency      string                 `xml:"DbtrAcct>Ccy,omitempty"`
+	PaymentEmitterAgent         *agentBICFI            `xml:"DbtrAgt"`
+	PaymentCharge               string                 `xml:"ChrgBr"`
+	PaymentTransactions         []creditTransactionV09 `xml:"CdtTrfTxInf"`
+}
+
+// creditTransactionV09 is the pain.001.001.09 layout of a CreditTransaction
+type creditTransactionV09 struct {
+	TransactID                    string           `xml:"PmtId>InstrId"`
+	TransactIDe2e                 string           `xml:"PmtId>EndToEndId"`
+	TransactAmount                TAmount          `xml:"Amt>InstdAmt"`
+	TransactCreditorAgent         *agentBICFI      `xml:"CdtrAgt"`
+	TransactCreditorName          string           `xml:"Cdtr>Nm"`
+	TransactCreditorPostalAddress *postalAddress24 `xml:"Cdtr>PstlAdr"`
+	TransactCreditorID            *partyIDXML      `xml:"Cdtr>Id"`
+	TransactCreditorIBAN          string           `xml:"CdtrAcct>Id>IBAN"`
+	TransactCreditorCurrency      string           `xml:"CdtrAcct>Ccy,omitempty"`
+	TransactMotif                 *RemittanceInfo  `xml:"RmtInf"`
+}
+
+// newCreditTransferV09 maps a CreditTransfer onto the pain.001.001.09 layout
+func newCreditTransferV09(doc *CreditTransfer) *creditTransferV09 {
+	v09 := &creditTransferV09{
+		XMLXsiLoc:              doc.XMLXsiLoc,
+		XMLNs:                  doc.XMLNs,
+		XMLXsi:                 doc.XMLXsi,
+		GroupHeaderMsgID:       doc.GroupHeaderMsgID,
+		GroupHeaderCreateDate:  doc.GroupHeaderCreateDate,
+		GroupHeaderTransactNo:  doc.GroupHeaderTransactNo,
+		GroupHeaderCtrlSum:     doc.GroupHeaderCtrlSum,
+		GroupHeaderEmitterName: doc.GroupHeaderEmitterName,
+		GroupHeaderEmitterID:   newPartyIDXMLOf(doc.GroupHeaderEmitterID),
+	}
+	for _, p := range doc.PaymentInfos {
+		pmtInf := creditPaymentInfoV09{
+			PaymentInfoID:               p.PaymentInfoID,
+			PaymentInfoMethod:           p.PaymentInfoMethod,
+			PaymentBatch:                p.PaymentBatch,
+			PaymentInfoTransactNo:       p.PaymentInfoTransactNo,
+			PaymentInfoCtrlSum:          p.PaymentInfoCtrlSum,
+			PaymentTypeInfo:             p.PaymentTypeInfo,
+			PaymentExecDate:             p.PaymentExecDate,
+			PaymentEmitterName:          p.PaymentEmitterName,
+			PaymentEmitterPostalAddress: newPostalAddress24Of(p.PaymentEmitterPostalAddress),
+			PaymentEmitterDebitorID:     newPartyIDXMLOf(p.PaymentEmitterDebitorID),
+			PaymentEmitterIBAN:          p.PaymentEmitterIBAN,
+			PaymentEmitterCurrency:      p.PaymentEmitterCurrency,
+			PaymentEmitterAgent:         newAgentBICFI(p.PaymentEmitterAgent),
+			PaymentCharge:               p.PaymentCharge,
+		}
+		for _, t := range p.PaymentTransactions {
+			pmtInf.PaymentTransactions = append(pmtInf.PaymentTransactions, newCreditTransactionV09(t))
+		}
+		v09.PaymentInfos = append(v09.PaymentInfos, pmtInf)
+	}
+	return v09
+}
+
+// newCreditTransactionV09 maps a CreditTransaction onto the pain.001.001.09 layout
+func newCreditTransactionV09(t CreditTransaction) creditTransactionV09 {
+	return creditTransactionV09{
+		TransactID:                    t.TransactID,
+		TransactIDe2e:                 t.TransactIDe2e,
+		TransactAmount:                t.TransactAmount,
+		TransactCreditorAgent:         newAgentBICFI(t.TransactCreditorAgent),
+		TransactCreditorName:          t.TransactCreditorName,
+		TransactCreditorPostalAddress: newPostalAddress24Of(t.TransactCreditorPostalAddress),
+		TransactCreditorID:            newPartyIDXMLOf(t.TransactCreditorID),
+		TransactCreditorIBAN:          t.TransactCreditorIBAN,
+		TransactCreditorCurrency:      t.TransactCreditorCurrency,
+		TransactMotif:                 t.TransactMotif,
+	}
+}
+
+// creditTransfer maps the pain.001.001.09 layout back onto a CreditTransfer
+func (v09 *creditTransferV09) creditTransfer() *CreditTransfer {
+	doc := &CreditTransfer{
+		GroupHeaderMsgID:       v09.GroupHeaderMsgID,
+		GroupHeaderCreateDate:  v09.GroupHeaderCreateDate,
+		GroupHeaderTransactNo:  v09.GroupHeaderTransactNo,
+		GroupHeaderCtrlSum:     v09.GroupHeaderCtrlSum,
+		GroupHeaderEmitterName: v09.GroupHeaderEmitterName,
+		GroupHeaderEmitterID:   v09.GroupHeaderEmitterID.partyIDRef(),
+	}
+	for _, p := range v09.PaymentInfos {
+		pmtInf := p.creditPaymentInfo()
+		for _, t := range p.PaymentTransactions {
+			pmtInf.PaymentTransactions = append(pmtInf.PaymentTransactions, t.creditTransaction())
+		}
+		doc.PaymentInfos = append(doc.PaymentInfos, pmtInf)
+	}
+	return doc
+}
+
+// creditPaymentInfo maps the pain.001.001.09 layout of a group back onto a CreditPaymentInfo without its transactions
+func (p *creditPaymentInfoV09) creditPaymentInfo() CreditPaymentInfo {
+	return CreditPaymentInfo{
+		PaymentInfoID:               p.PaymentInfoID,
+		PaymentInfoMethod:           p.PaymentInfoMethod,
+		PaymentBatch:                p.PaymentBatch,
+		PaymentInfoTransactNo:       p.PaymentInfoTransactNo,
+		PaymentInfoCtrlSum:          p.PaymentInfoCtrlSum,
+		PaymentTypeInfo:             p.PaymentTypeInfo,
+		PaymentExecDate:             p.PaymentExecDate,
+		PaymentEmitterName:          p.PaymentEmitterName,
+		PaymentEmitterPostalAddress: p.PaymentEmitterPostalAddress.postalAddressRef(),
+		PaymentEmitterDebitorID:     p.PaymentEmitterDebitorID.partyIDRef(),
+		PaymentEmitterIBAN:          p.PaymentEmitterIBAN,
+		PaymentEmitterCurrency:      p.PaymentEmitterCurrency,
+		PaymentEmitterAgent:         p.PaymentEmitterAgent.agent(),
+		PaymentCharge:               p.PaymentCharge,
+	}
+}
+
+// creditTransaction maps the pain.001.001.09 layout back onto a CreditTransaction
+func (v09 creditTransactionV09) creditTransaction() CreditTransaction {
+	return CreditTransaction{
+		TransactID:                    v09.TransactID,
+		TransactIDe2e:                 v09.TransactIDe2e,
+		TransactAmount:                v09.TransactAmount,
+		TransactCreditorAgent:         v09.TransactCreditorAgent.agent(),
+		TransactCreditorName:          v09.TransactCreditorName,
+		TransactCreditorPostalAddress: v09.TransactCreditorPostalAddress.postalAddressRef(),
+		TransactCreditorID:            v09.TransactCreditorID.partyIDRef(),
+		TransactCreditorIBAN:          v09.TransactCreditorIBAN,
+		TransactCreditorCurrency:      v09.TransactCreditorCurrency,
+		TransactMotif:                 v09.TransactMotif,
+	}
+}
@@ -0,0 +1,167 @@
+package sepa
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Date is a calendar date without time of day or location, written as ISODate "2006-01-02".
+// The zero value is no date.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// DateOf returns the date of t in its location
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{Year: y, Month: m, Day: d}
+}
+
+// ParseDate reads a date written as "2006-01-02", a date with a time of day is refused
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return Date{}, errors.New("invalid date " + s + ", expected YYYY-MM-DD")
+	}
+	return DateOf(t), nil
+}
+
+// IsZero tells whether no date is set
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+// IsValid tells whether the date exists in the calendar, 2017-02-30 does not
+func (d Date) IsValid() bool {
+	return DateOf(d.In(time.UTC)) == d
+}
+
+// In returns the start of the day in loc
+func (d Date) In(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// Before tells whether d is before o
+func (d Date) Before(o Date) bool {
+	return d.In(time.UTC).Before(o.In(time.UTC))
+}
+
+// After tells whether d is after o
+func (d Date) After(o Date) bool {
+	return o.Before(d)
+}
+
+// String returns the date as "2006-01-02", the empty string for the zero value
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// MarshalText writes the date as "2006-01-02"
+func (d Date) MarshalText() ([]byte, error) {
+	if !d.IsZero() && !d.IsValid() {
+		return nil, errors.New("invalid date " + d.String())
+	}
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText reads a date written as "2006-01-02", empty text is the zero value
+func (d *Date) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*d = Date{}
+		return nil
+	}
+	date, err := ParseDate(string(text))
+	if err != nil {
+		return err
+	}
+	*d = date
+	return nil
+}
+
+// DateTime is a point in time written as ISODateTime with its UTC offset, "2006-01-02T15:04:05+02:00".
+// A time read without offset is written back without one. The zero value is no time.
+type DateTime struct {
+	t time.Time
+	// floating is set for a time read without UTC offset
+	floating bool
+}
+
+// dateTimeLayout and floatingLayout are the ISODateTime layouts with and without UTC offset
+const (
+	dateTimeLayout = "2006-01-02T15:04:05.999999999Z07:00"
+	floatingLayout = "2006-01-02T15:04:05.999999999"
+)
+
+// DateTimeOf returns the point in time t, written with the UTC offset of its location
+func DateTimeOf(t time.Time) DateTime {
+	return DateTime{t: t}
+}
+
+// ParseDateTime reads a point in time written as "2006-01-02T15:04:05", with optional fraction of second and UTC offset
+func ParseDateTime(s string) (DateTime, error) {
+	if t, err := time.Parse(dateTimeLayout, s); err == nil {
+		return DateTime{t: t}, nil
+	}
+	t, err := time.Parse(floatingLayout, s)
+	if err != nil {
+		return DateTime{}, errors.New("invalid date time " + s + ", expected YYYY-MM-DDThh:mm:ss")
+	}
+	return DateTime{t: t, floating: true}, nil
+}
+
+// Time returns the point in time, in UTC when it was read without offset
+func (t DateTime) Time() time.Time {
+	return t.t
+}
+
+// IsZero tells whether no time is set
+func (t DateTime) IsZero() bool {
+	return t.t.IsZero()
+}
+
+// String returns the time as "2006-01-02T15:04:05+02:00", the empty string for the zero value
+func (t DateTime) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	if t.floating {
+		return t.t.Format(floatingLayout)
+	}
+	return t.t.Format(dateTimeLayout)
+}
+
+// MarshalText writes the time as "2006-01-02T15:04:05+02:00"
+func (t DateTime) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText reads a time written as "2006-01-02T15:04:05", with optional UTC offset, empty text is the zero value
+func (t *DateTime) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*t = DateTime{}
+		return nil
+	}
+	dt, err := ParseDateTime(string(text))
+	if err != nil {
+		return err
+	}
+	*t = dt
+	return nil
+}
+
+// checkDate returns an error naming the field if the date is missing or does not exist
+func checkDate(field string, d Date) error {
+	if d.IsZero() {
+		return errors.New("missing " + field)
+	}
+	if !d.IsValid() {
+		return errors.New("invalid " + field + " " + d.String())
+	}
+	return nil
+}
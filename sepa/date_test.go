@@ -0,0 +1,82 @@
+package sepa
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+// day returns the date written in s, for test tables
+func day(s string) Date {
+	d, err := ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// at returns the time written in s in UTC, for test tables
+func at(s string) time.Time {
+	t, err := ParseDateTime(s)
+	if err != nil {
+		panic(err)
+	}
+	return t.Time()
+}
+
+func TestParseDate(t *testing.T) {
+	d, err := ParseDate("2017-06-11")
+	if err != nil || d != (Date{2017, time.June, 11}) || d.String() != "2017-06-11" {
+		t.Error("Expected 2017-06-11", "got", d, err)
+	}
+	for _, s := range []string{"", "11.06.2017", "2017-06-07T14:39:33", "2017-02-30", "17-06-11"} {
+		if _, err := ParseDate(s); err == nil {
+			t.Error("Expected ParseDate return an error for", s)
+		}
+	}
+	if (Date{2017, time.February, 30}).IsValid() || !(Date{2016, time.February, 29}).IsValid() {
+		t.Error("Expected 2017-02-30 invalid and 2016-02-29 valid")
+	}
+	if !day("2017-01-01").Before(day("2017-06-11")) || day("2017-01-01").After(day("2017-06-11")) {
+		t.Error("Expected 2017-01-01 before 2017-06-11")
+	}
+	if DateOf(time.Date(2017, 6, 11, 23, 30, 0, 0, time.FixedZone("", -5*60*60))) != day("2017-06-11") {
+		t.Error("Expected the date in the location of the time")
+	}
+	if _, err := xml.Marshal(struct{ D Date }{Date{2017, time.February, 30}}); err == nil {
+		t.Error("Expected Marshal return an error for 2017-02-30")
+	}
+}
+func TestParseDateTime(t *testing.T) {
+	TTest := []struct {
+		s        string
+		expected time.Time
+	}{
+		{"2017-06-07T14:39:33", time.Date(2017, 6, 7, 14, 39, 33, 0, time.UTC)},
+		{"2017-06-07T14:39:33.5", time.Date(2017, 6, 7, 14, 39, 33, 500000000, time.UTC)},
+		{"2017-06-07T14:39:33Z", time.Date(2017, 6, 7, 14, 39, 33, 0, time.UTC)},
+		{"2017-06-07T14:39:33+02:00", time.Date(2017, 6, 7, 12, 39, 33, 0, time.UTC)},
+	}
+	for _, test := range TTest {
+		dt, err := ParseDateTime(test.s)
+		if err != nil {
+			t.Error("Expected ParseDateTime return nil for", test.s, "got", err)
+			continue
+		}
+		if !dt.Time().Equal(test.expected) {
+			t.Error("Expected", test.expected, "got", dt.Time())
+		}
+		// written back the way it was read
+		if dt.String() != test.s {
+			t.Error("Expected", test.s, "got", dt.String())
+		}
+	}
+	for _, s := range []string{"", "2017-06-07", "2017-06-07 14:39:33", "07.06.2017 14:39"} {
+		if _, err := ParseDateTime(s); err == nil {
+			t.Error("Expected ParseDateTime return an error for", s)
+		}
+	}
+	if s := DateTimeOf(time.Date(2017, 6, 7, 14, 39, 33, 0, time.FixedZone("", -3*60*60))).String(); s != "2017-06-07T14:39:33-03:00" {
+		t.Error("Expected 2017-06-07T14:39:33-03:00", "got", s)
+	}
+}
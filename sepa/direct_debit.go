@@ -4,139 +4,516 @@ import (
 	"encoding/xml"
 	"errors"
 	"github.com/flofuenf/gosepa/lib"
-	"strings"
-	"time"
+	"io"
+	"strconv"
 )
 
 // DirectDebit is the SEPA format for the document containing all direct debits
 type DirectDebit struct {
-	XMLName                     xml.Name           `xml:"Document"`
-	XMLXsiLoc                   string             `xml:"xsi:schemaLocation,attr"`
-	XMLNs                       string             `xml:"xmlns,attr"`
-	XMLXsi                      string             `xml:"xmlns:xsi,attr"`
-	GroupHeaderMsgID            string             `xml:"CstmrDrctDbtInitn>GrpHdr>MsgId"`
-	GroupHeaderCreateDate       string             `xml:"CstmrDrctDbtInitn>GrpHdr>CreDtTm"`
-	GroupHeaderTransactNo       int                `xml:"CstmrDrctDbtInitn>GrpHdr>NbOfTxs"`
-	GroupHeaderCtrlSum          float64            `xml:"CstmrDrctDbtInitn>GrpHdr>CtrlSum"`
-	GroupHeaderEmitterName      string             `xml:"CstmrDrctDbtInitn>GrpHdr>InitgPty>Nm"`
-	PaymentInfoID               string             `xml:"CstmrDrctDbtInitn>PmtInf>PmtInfId"`
-	PaymentInfoMethod           string             `xml:"CstmrDrctDbtInitn>PmtInf>PmtMtd"`
-	PaymentBatch                string             `xml:"CstmrDrctDbtInitn>PmtInf>BtchBookg"`
-	PaymentInfoTransactNo       int                `xml:"CstmrDrctDbtInitn>PmtInf>NbOfTxs"`
-	PaymentInfoCtrlSum          float64            `xml:"CstmrDrctDbtInitn>PmtInf>CtrlSum"`
-	PaymentTypeInfo             string             `xml:"CstmrDrctDbtInitn>PmtInf>PmtTpInf>SvcLvl>Cd"`
-	PaymentType                 string             `xml:"CstmrDrctDbtInitn>PmtInf>PmtTpInf>LclInstrm>Cd"`
-	PaymentTypeSequence         string             `xml:"CstmrDrctDbtInitn>PmtInf>PmtTpInf>SeqTp"`
-	PaymentExecDate             string             `xml:"CstmrDrctDbtInitn>PmtInf>ReqdColltnDt"`
-	PaymentEmitterName          string             `xml:"CstmrDrctDbtInitn>PmtInf>Cdtr>Nm"`
-	PaymentEmitterPostalCountry string             `xml:"CstmrDrctDbtInitn>PmtInf>Cdtr>PstlAdr>Ctry"`
-	PaymentEmitterPostalAddress []string           `xml:"CstmrDrctDbtInitn>PmtInf>Cdtr>PstlAdr>AdrLine"`
-	PaymentEmitterIBAN          string             `xml:"CstmrDrctDbtInitn>PmtInf>CdtrAcct>Id>IBAN"`
-	PaymentEmitterBIC           string             `xml:"CstmrDrctDbtInitn>PmtInf>CdtrAgt>FinInstnId>BIC"`
-	PaymentEmitterID            string             `xml:"CstmrDrctDbtInitn>PmtInf>CdtrSchmeId>Id>PrvtId>Othr>Id"`
-	PaymentEmitterProprietary   string             `xml:"CstmrDrctDbtInitn>PmtInf>CdtrSchmeId>Id>PrvtId>Othr>SchmeNm>Prtry"`
-	PaymentTransactions         []DebitTransaction `xml:"CstmrDrctDbtInitn>PmtInf>DrctDbtTxInf"`
+	XMLName                xml.Name           `xml:"Document"`
+	XMLXsiLoc              string             `xml:"xsi:schemaLocation,attr"`
+	XMLNs                  string             `xml:"xmlns,attr"`
+	XMLXsi                 string             `xml:"xmlns:xsi,attr"`
+	GroupHeaderMsgID       string             `xml:"CstmrDrctDbtInitn>GrpHdr>MsgId"`
+	GroupHeaderCreateDate  DateTime           `xml:"CstmrDrctDbtInitn>GrpHdr>CreDtTm"`
+	GroupHeaderTransactNo  int                `xml:"CstmrDrctDbtInitn>GrpHdr>NbOfTxs"`
+	GroupHeaderCtrlSum     Amount             `xml:"CstmrDrctDbtInitn>GrpHdr>CtrlSum"`
+	GroupHeaderEmitterName string             `xml:"CstmrDrctDbtInitn>GrpHdr>InitgPty>Nm"`
+	GroupHeaderEmitterID   *PartyID           `xml:"CstmrDrctDbtInitn>GrpHdr>InitgPty>Id"`
+	PaymentInfos           []DebitPaymentInfo `xml:"CstmrDrctDbtInitn>PmtInf"`
+
+	// paymentInfoID is the base of the PmtInfId of every group
+	paymentInfoID string
+	// creditor holds the constants and creditor information every new group starts from
+	creditor DebitPaymentInfo
+	// charset and truncate apply to the text fields added to the document
+	charset  Charset
+	truncate bool
+}
+
+// DebitPaymentInfo is a payment information group (PmtInf) sharing sequence type, collection date and local instrument
+type DebitPaymentInfo struct {
+	PaymentInfoID               string             `xml:"PmtInfId"`
+	PaymentInfoMethod           string             `xml:"PmtMtd"`
+	PaymentBatch                string             `xml:"BtchBookg"`
+	PaymentInfoTransactNo       int                `xml:"NbOfTxs"`
+	PaymentInfoCtrlSum          Amount             `xml:"CtrlSum"`
+	PaymentTypeInfo             string             `xml:"PmtTpInf>SvcLvl>Cd"`
+	PaymentType                 string             `xml:"PmtTpInf>LclInstrm>Cd"`
+	PaymentTypeSequence         string             `xml:"PmtTpInf>SeqTp"`
+	PaymentExecDate             Date               `xml:"ReqdColltnDt"`
+	PaymentEmitterName          string             `xml:"Cdtr>Nm"`
+	PaymentEmitterPostalAddress *PostalAddress     `xml:"Cdtr>PstlAdr"`
+	PaymentEmitterCreditorID    *PartyID           `xml:"Cdtr>Id"`
+	PaymentEmitterIBAN          string             `xml:"CdtrAcct>Id>IBAN"`
+	PaymentEmitterCurrency      string             `xml:"CdtrAcct>Ccy,omitempty"`
+	PaymentEmitterAgent         Agent              `xml:"CdtrAgt"`
+	PaymentEmitterID            string             `xml:"CdtrSchmeId>Id>PrvtId>Othr>Id"`
+	PaymentEmitterProprietary   string             `xml:"CdtrSchmeId>Id>PrvtId>Othr>SchmeNm>Prtry"`
+	PaymentTransactions         []DebitTransaction `xml:"DrctDbtTxInf"`
 }
 
 // DebitTransaction is the debit transfer SEPA format
 type DebitTransaction struct {
-	TransactIDe2e                string  `xml:"PmtId>EndToEndId"`
-	TransactAmount               TAmount `xml:"InstdAmt"`
-	TransactMandantId            string  `xml:"DrctDbtTx>MndtRltdInf>MndtId"`
-	TransactMandantSignatureDate string  `xml:"DrctDbtTx>MndtRltdInf>DtOfSgntr"`
-	TransactCreditorBic          string  `xml:"DbtrAgt>FinInstnId>BIC"`
-	TransactCreditorName         string  `xml:"Dbtr>Nm"`
-	TransactCreditorIBAN         string  `xml:"DbtrAcct>Id>IBAN"`
-	TransactMotif                string  `xml:"RmtInf>Ustrd"`
+	TransactIDe2e                string          `xml:"PmtId>EndToEndId"`
+	TransactAmount               TAmount         `xml:"InstdAmt"`
+	TransactMandantId            string          `xml:"DrctDbtTx>MndtRltdInf>MndtId"`
+	TransactMandantSignatureDate Date            `xml:"DrctDbtTx>MndtRltdInf>DtOfSgntr"`
+	TransactCreditorSchemeID     *CreditorScheme `xml:"DrctDbtTx>CdtrSchmeId"`
+	TransactDebtorAgent          Agent           `xml:"DbtrAgt"`
+	TransactDebtorName           string          `xml:"Dbtr>Nm"`
+	TransactDebtorPostalAddress  *PostalAddress  `xml:"Dbtr>PstlAdr"`
+	TransactDebtorID             *PartyID        `xml:"Dbtr>Id"`
+	TransactDebtorIBAN           string          `xml:"DbtrAcct>Id>IBAN"`
+	TransactDebtorCurrency       string          `xml:"DbtrAcct>Ccy,omitempty"`
+	TransactMotif                *RemittanceInfo `xml:"RmtInf"`
+
+	// TransactStatus is the status reported by the bank, see ApplyStatusReport
+	TransactStatus *TransactionStatus `xml:"-"`
+}
+
+// CreditorScheme is the creditor scheme identification of a transaction collected under another creditor scheme ID
+// than its group, nil otherwise
+type CreditorScheme struct {
+	ID string `xml:"Id>PrvtId>Othr>Id"`
+	// Proprietary is the scheme name, always SEPA
+	Proprietary string `xml:"Id>PrvtId>Othr>SchmeNm>Prtry"`
+}
+
+// creditorScheme has the layout of CreditorScheme without its MarshalXML method
+type creditorScheme CreditorScheme
+
+// MarshalXML writes the scheme name SEPA when it is empty, the schemas require it
+func (c CreditorScheme) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if c.Proprietary == "" {
+		c.Proprietary = "SEPA"
+	}
+	return e.EncodeElement(creditorScheme(c), start)
+}
+
+// newCreditorScheme returns the creditor scheme identification of a creditor scheme ID, nil if it is empty
+func newCreditorScheme(id string) *CreditorScheme {
+	if id == "" {
+		return nil
+	}
+	return &CreditorScheme{ID: id, Proprietary: "SEPA"}
+}
+
+// CreditorSchemeID returns the creditor scheme ID a transaction of the group is collected under,
+// its own TransactCreditorSchemeID if set, the one of the group otherwise
+func (p *DebitPaymentInfo) CreditorSchemeID(t DebitTransaction) string {
+	if t.TransactCreditorSchemeID != nil && t.TransactCreditorSchemeID.ID != "" {
+		return t.TransactCreditorSchemeID.ID
+	}
+	return p.PaymentEmitterID
+}
+
+// Creditor returns the creditor of the group
+func (p *DebitPaymentInfo) Creditor() Party {
+	creditor := Party{Name: p.PaymentEmitterName}
+	if p.PaymentEmitterPostalAddress != nil {
+		creditor.Address = *p.PaymentEmitterPostalAddress
+	}
+	if p.PaymentEmitterCreditorID != nil {
+		creditor.ID = *p.PaymentEmitterCreditorID
+	}
+	return creditor
+}
+
+// CreditorAccount returns the account the group is collected to
+func (p *DebitPaymentInfo) CreditorAccount() Account {
+	return Account{IBAN: p.PaymentEmitterIBAN, Currency: p.PaymentEmitterCurrency}
+}
+
+// Debtor returns the debtor of the transaction
+func (t DebitTransaction) Debtor() Party {
+	p := Party{Name: t.TransactDebtorName}
+	if t.TransactDebtorPostalAddress != nil {
+		p.Address = *t.TransactDebtorPostalAddress
+	}
+	if t.TransactDebtorID != nil {
+		p.ID = *t.TransactDebtorID
+	}
+	return p
+}
+
+// DebtorAccount returns the account the transaction is collected from
+func (t DebitTransaction) DebtorAccount() Account {
+	return Account{IBAN: t.TransactDebtorIBAN, Currency: t.TransactDebtorCurrency}
+}
+
+// Collection is a direct debit to add to a document
+type Collection struct {
+	// ID is written as EndToEndId
+	ID            string
+	Amount        Amount
+	Currency      string
+	Debtor        Party
+	DebtorAccount Account
+	// DebtorAgent is written as NotProvided when it is the zero value
+	DebtorAgent Agent
+	Description string
+	MandateID   string
+	// MandateSignatureDate is the date the mandate was signed, not after the collection date
+	MandateSignatureDate Date
+	// SequenceType is one of SequenceFirst, SequenceRecurring, SequenceOneOff and SequenceFinal
+	SequenceType string
+	// CollectionDate is the collection date given to the document when zero
+	CollectionDate Date
 }
 
-// InitDoc fixes every constant in the document + emitter information
+// Sequence types of a direct debit
+const (
+	SequenceFirst     = "FRST"
+	SequenceRecurring = "RCUR"
+	SequenceOneOff    = "OOFF"
+	SequenceFinal     = "FNAL"
+)
+
+// NewDirectDebit returns a document ready for AddTransaction, built from a validated config
+func NewDirectDebit(c DirectDebitConfig) (*DirectDebit, error) {
+	c = c.withDefaults()
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	doc := &DirectDebit{}
+	if err := doc.init(c, DateTimeOf(c.CreationDate)); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// InitDoc fixes every constant in the document + emitter information.
+// executionDate is the collection date of transactions added without one.
+//
+// Deprecated: use NewDirectDebit, its named fields can't be swapped by mistake.
 func (doc *DirectDebit) InitDoc(msgID string, paymentInfoID string, creationDate string, executionDate string,
 	emitterName string, emitterIBAN string, emitterBIC string, emitterID string, countryCode string, street string, city string) error {
-	emitterIBAN = strings.Join(strings.Fields(emitterIBAN), "")
-	if _, err := time.Parse("2006-01-02T15:04:05", creationDate); err != nil {
+	c := DirectDebitConfig{
+		Version:          Pain008DKV02,
+		MsgID:            msgID,
+		PaymentInfoID:    paymentInfoID,
+		Initiator:        Party{Name: emitterName, Address: PostalAddress{Country: countryCode, AddressLines: []string{street, city}}},
+		CreditorAccount:  Account{IBAN: emitterIBAN}.normalized(),
+		CreditorAgent:    Agent{BIC: emitterBIC}.orNotProvided(),
+		CreditorSchemeID: emitterID,
+		LocalInstrument:  "CORE",
+	}
+	c.Creditor = c.Initiator
+	created, err := ParseDateTime(creationDate)
+	if err != nil {
 		return err
 	}
-	if _, err := time.Parse("2006-01-02", executionDate); err != nil {
+	if c.CollectionDate, err = ParseDate(executionDate); err != nil {
 		return err
 	}
-	if !lib.IsValid(emitterIBAN) {
+	if !lib.IsValid(c.CreditorAccount.IBAN) {
 		return errors.New("invalid emitter IBAN")
 	}
+	return doc.init(c, created)
+}
 
-	// general xml stuff
-	doc.XMLXsiLoc = "urn:iso:std:iso:20022:tech:xsd:pain.008.003.02 pain.008.003.02.xsd"
-	doc.XMLNs = "urn:iso:std:iso:20022:tech:xsd:pain.008.003.02"
-	doc.XMLXsi = "http://www.w3.org/2001/XMLSchema-instance"
+// init fixes every constant in the group header and the creditor information every new group starts from
+func (doc *DirectDebit) init(c DirectDebitConfig, creationDate DateTime) error {
+	// general xml stuff, the groups of a previous init don't restrict the version
+	doc.PaymentInfos, doc.creditor = nil, DebitPaymentInfo{}
+	if err := doc.SetVersion(c.Version); err != nil {
+		return err
+	}
+	doc.charset, doc.truncate = c.Charset, c.Truncate
+	if err := checkID("message ID", c.MsgID); err != nil {
+		return err
+	}
+	if err := checkID("payment info ID", c.PaymentInfoID); err != nil {
+		return err
+	}
+	if err := checkID("creditor scheme ID", c.CreditorSchemeID); err != nil {
+		return err
+	}
+	var err error
+	if c.Initiator.Name, err = doc.text().name(c.Initiator.Name); err != nil {
+		return errors.New("initiator : " + err.Error())
+	}
+	if c.Creditor, err = doc.text().party(c.Creditor); err != nil {
+		return errors.New("creditor : " + err.Error())
+	}
+	doc.XMLXsi = xsiNamespace
 
 	// group header
-	doc.GroupHeaderMsgID = msgID
+	doc.GroupHeaderMsgID = c.MsgID
 	doc.GroupHeaderCreateDate = creationDate
-	doc.GroupHeaderEmitterName = emitterName
+	doc.GroupHeaderEmitterName = c.Initiator.Name
+	doc.GroupHeaderEmitterID = c.Initiator.ID.ref()
+	doc.GroupHeaderTransactNo = 0
+	doc.GroupHeaderCtrlSum = Amount{}
 
 	// general document information
-	doc.PaymentInfoID = paymentInfoID
-	doc.PaymentInfoMethod = "DD"
-	doc.PaymentBatch = "true"    //always true??
-	doc.PaymentTypeInfo = "SEPA" // always SEPA
-	doc.PaymentType = "CORE"
-	doc.PaymentTypeSequence = "FRST"
-	doc.PaymentExecDate = executionDate
-	doc.PaymentEmitterName = emitterName
-	doc.PaymentEmitterPostalCountry = countryCode
-	doc.PaymentEmitterPostalAddress = make([]string, 0)
-	doc.PaymentEmitterPostalAddress = append(doc.PaymentEmitterPostalAddress, street)
-	doc.PaymentEmitterPostalAddress = append(doc.PaymentEmitterPostalAddress, city)
-	doc.PaymentEmitterIBAN = emitterIBAN
-	doc.PaymentEmitterBIC = emitterBIC
-	doc.PaymentEmitterID = emitterID
-	doc.PaymentEmitterProprietary = "SEPA"
+	doc.paymentInfoID = c.PaymentInfoID
+	doc.creditor = DebitPaymentInfo{
+		PaymentInfoMethod:           "DD",
+		PaymentBatch:                "true", //always true??
+		PaymentTypeInfo:             "SEPA", // always SEPA
+		PaymentType:                 c.LocalInstrument,
+		PaymentTypeSequence:         SequenceFirst,
+		PaymentExecDate:             c.CollectionDate,
+		PaymentEmitterName:          c.Creditor.Name,
+		PaymentEmitterPostalAddress: c.Creditor.postalAddress(),
+		PaymentEmitterCreditorID:    c.Creditor.ID.ref(),
+		PaymentEmitterIBAN:          c.CreditorAccount.IBAN,
+		PaymentEmitterCurrency:      c.CreditorAccount.Currency,
+		PaymentEmitterAgent:         c.CreditorAgent,
+		PaymentEmitterID:            c.CreditorSchemeID,
+		PaymentEmitterProprietary:   "SEPA",
+	}
+	return nil
+}
 
+// SetVersion selects the schema version (Pain008DKV02, Pain008V02 or Pain008V08) the document is written in.
+// The transactions already added are kept. Pain008DKV02 returns an error if the creditor is identified, it has no room for it.
+func (doc *DirectDebit) SetVersion(version string) error {
+	if err := checkVersion(version, Pain008DKV02, Pain008V02, Pain008V08); err != nil {
+		return err
+	}
+	if version == Pain008DKV02 {
+		identified := doc.creditor.PaymentEmitterCreditorID != nil
+		for _, p := range doc.PaymentInfos {
+			identified = identified || p.PaymentEmitterCreditorID != nil
+		}
+		if identified {
+			return errors.New("creditor : identification not allowed by " + Pain008DKV02)
+		}
+	}
+	doc.XMLXsiLoc = schemaLocation(version)
+	doc.XMLNs = namespace(version)
 	return nil
 }
 
-// AddTransaction adds a transfer transaction and adjust the transaction number and the sum control
-func (doc *DirectDebit) AddTransaction(id string, amount float64, currency string, creditorName string,
-	creditorIBAN string, bic string, description string, mandantId string, mandantSignatureDate string) error {
-	creditorIBAN = strings.Join(strings.Fields(creditorIBAN), "")
-	if !lib.IsValid(creditorIBAN) {
-		return errors.New("invalid creditor IBAN")
-	}
-	if lib.DecimalsNumber(amount) > 2 {
-		return errors.New("amount 2 decimals only")
-	}
-	doc.PaymentTransactions = append(doc.PaymentTransactions, DebitTransaction{
-		TransactIDe2e:                id,
-		TransactAmount:               TAmount{Amount: amount, Currency: currency},
-		TransactMandantId:            mandantId,
-		TransactMandantSignatureDate: mandantSignatureDate,
-		TransactCreditorBic:          bic,
-		TransactCreditorName:         creditorName,
-		TransactCreditorIBAN:         creditorIBAN,
-		TransactMotif:                description,
+// Version returns the schema version of the document
+func (doc *DirectDebit) Version() string {
+	return versionOf(doc.XMLNs)
+}
+
+// SetLocalInstrument sets the local instrument (CORE, COR1 or B2B) of the transactions added from now on
+func (doc *DirectDebit) SetLocalInstrument(code string) error {
+	switch code {
+	case "CORE", "COR1", "B2B":
+		doc.creditor.PaymentType = code
+		return nil
+	}
+	return errors.New("invalid local instrument")
+}
+
+// SetCharset sets the character set of the text fields added from now on
+func (doc *DirectDebit) SetCharset(c Charset) {
+	doc.charset = c
+}
+
+// SetTruncate tells whether the text fields added from now on are cut to their length instead of returning an error
+func (doc *DirectDebit) SetTruncate(truncate bool) {
+	doc.truncate = truncate
+}
+
+// text returns the rules the text fields added to the document are checked against
+func (doc *DirectDebit) text() textRules {
+	return newTextRules(doc.Version(), doc.charset, doc.truncate)
+}
+
+// AddTransaction adds a transfer transaction to the payment information group matching its sequence type,
+// collection date and local instrument and adjust the transaction number and the sum control.
+// Dates are written as "2006-01-02", an empty collectionDate means the collection date given to the document.
+func (doc *DirectDebit) AddTransaction(id string, amount Amount, currency string, debtorName string,
+	debtorIBAN string, bic string, description string, mandantId string, mandantSignatureDate string,
+	sequenceType string, collectionDate string) error {
+	signed, err := ParseDate(mandantSignatureDate)
+	if err != nil {
+		return errors.New("mandate signature date : " + err.Error())
+	}
+	var collected Date
+	if collectionDate != "" {
+		if collected, err = ParseDate(collectionDate); err != nil {
+			return errors.New("collection date : " + err.Error())
+		}
+	}
+	return doc.AddCollection(Collection{
+		ID:                   id,
+		Amount:               amount,
+		Currency:             currency,
+		Debtor:               Party{Name: debtorName},
+		DebtorAccount:        Account{IBAN: debtorIBAN},
+		DebtorAgent:          Agent{BIC: bic},
+		Description:          description,
+		MandateID:            mandantId,
+		MandateSignatureDate: signed,
+		SequenceType:         sequenceType,
+		CollectionDate:       collected,
 	})
-	doc.GroupHeaderTransactNo++
-	doc.PaymentInfoTransactNo++
+}
+
+// AddCollection adds a direct debit to the payment information group matching its sequence type,
+// collection date and local instrument and adjust the transaction number and the sum control
+func (doc *DirectDebit) AddCollection(d Collection) error {
+	d, err := doc.checkCollection(d)
+	if err != nil {
+		return err
+	}
+	transaction := newDebitTransaction(d)
+	amount := transaction.TransactAmount.Amount
 
-	amountCents, err := lib.ToCents(amount)
+	pmtInf := doc.paymentInfoFor(d.SequenceType, d.CollectionDate, doc.creditor.PaymentType)
+	pmtInfSum, err := pmtInf.PaymentInfoCtrlSum.Add(amount)
 	if err != nil {
-		return errors.New("in AddTransaction can't convert amount in cents")
+		return err
 	}
-	cumulusCents, err := lib.ToCents(doc.GroupHeaderCtrlSum)
+	groupSum, err := doc.GroupHeaderCtrlSum.Add(amount)
 	if err != nil {
-		return errors.New("in AddTransaction can't convert control sum in cents")
+		return err
 	}
 
-	cumulusEuro, err := lib.ToEuro(cumulusCents + amountCents)
+	pmtInf.PaymentTransactions = append(pmtInf.PaymentTransactions, transaction)
+	pmtInf.PaymentInfoTransactNo++
+	pmtInf.PaymentInfoCtrlSum = pmtInfSum
+	doc.GroupHeaderTransactNo++
+	doc.GroupHeaderCtrlSum = groupSum
+	return nil
+}
+
+// checkCollection validates a direct debit and returns it with its amount in the fraction digits of its currency,
+// its text fields in the character set of the document and within their lengths, its account normalized and the
+// collection date of the document when it has none. Its errors name the transaction.
+func (doc *DirectDebit) checkCollection(d Collection) (Collection, error) {
+	checked, err := doc.validateCollection(d)
 	if err != nil {
-		return errors.New("in AddTransaction can't convert cumulus in euro")
+		return d, errors.New("transaction " + d.ID + " : " + err.Error())
 	}
+	return checked, nil
+}
 
-	doc.GroupHeaderCtrlSum = cumulusEuro
-	doc.PaymentInfoCtrlSum = cumulusEuro
+func (doc *DirectDebit) validateCollection(d Collection) (Collection, error) {
+	if err := checkID("end to end ID", d.ID); err != nil {
+		return d, err
+	}
+	if err := checkID("mandate ID", d.MandateID); err != nil {
+		return d, err
+	}
+	var err error
+	if d.Debtor, err = doc.text().party(d.Debtor); err != nil {
+		return d, errors.New("debtor : " + err.Error())
+	}
+	if d.Description, err = doc.text().text("description", d.Description, max140Text); err != nil {
+		return d, err
+	}
+	d.DebtorAccount = d.DebtorAccount.normalized()
+	if err := d.DebtorAccount.Validate(); err != nil {
+		return d, errors.New("debtor account : " + err.Error())
+	}
+	if err := d.Debtor.Validate(); err != nil {
+		return d, errors.New("debtor : " + err.Error())
+	}
+	if err := d.DebtorAgent.Validate(); err != nil {
+		return d, errors.New("debtor agent : " + err.Error())
+	}
+	amount, err := checkAmount(d.Amount, d.Currency)
+	if err != nil {
+		return d, err
+	}
+	d.Amount = amount
+	switch d.SequenceType {
+	case SequenceFirst, SequenceRecurring, SequenceOneOff, SequenceFinal:
+	default:
+		return d, errors.New("invalid sequence type")
+	}
+	if d.CollectionDate.IsZero() {
+		d.CollectionDate = doc.creditor.PaymentExecDate
+	}
+	if err := checkDate("collection date", d.CollectionDate); err != nil {
+		return d, err
+	}
+	if err := checkDate("mandate signature date", d.MandateSignatureDate); err != nil {
+		return d, err
+	}
+	if d.MandateSignatureDate.After(d.CollectionDate) {
+		return d, errors.New("mandate signature date after the collection date")
+	}
+	return d, nil
+}
+
+// newDebitTransaction returns the transaction of a direct debit checked by checkCollection
+func newDebitTransaction(d Collection) DebitTransaction {
+	return DebitTransaction{
+		TransactIDe2e:                d.ID,
+		TransactAmount:               TAmount{Amount: d.Amount, Currency: d.Currency},
+		TransactMandantId:            d.MandateID,
+		TransactMandantSignatureDate: d.MandateSignatureDate,
+		TransactDebtorAgent:          d.DebtorAgent.orNotProvided(),
+		TransactDebtorName:           d.Debtor.Name,
+		TransactDebtorPostalAddress:  d.Debtor.postalAddress(),
+		TransactDebtorID:             d.Debtor.ID.ref(),
+		TransactDebtorIBAN:           d.DebtorAccount.IBAN,
+		TransactDebtorCurrency:       d.DebtorAccount.Currency,
+		TransactMotif:                newRemittanceInfo(d.Description),
+	}
+}
+
+// paymentInfoFor returns the group for the given sequence type, collection date and local instrument,
+// creating it if needed. The first group gets the payment info ID given to InitDoc, the next ones the first free
+// numbered suffix, the ID being shortened so that they stay within 35 characters.
+func (doc *DirectDebit) paymentInfoFor(sequenceType string, collectionDate Date, localInstrument string) *DebitPaymentInfo {
+	if p := doc.findPaymentInfo(sequenceType, collectionDate, localInstrument); p != nil {
+		return p
+	}
+	pmtInf := doc.creditor
+	pmtInf.PaymentEmitterPostalAddress = doc.creditor.PaymentEmitterPostalAddress.clone()
+	pmtInf.PaymentInfoID = doc.paymentInfoID
+	if len(doc.PaymentInfos) > 0 {
+		for n := len(doc.PaymentInfos) + 1; ; n++ {
+			suffix := "-" + strconv.Itoa(n)
+			pmtInf.PaymentInfoID = truncateText(doc.paymentInfoID, max35Text-len(suffix)) + suffix
+			if doc.PaymentInfo(pmtInf.PaymentInfoID) == nil {
+				break
+			}
+		}
+	}
+	pmtInf.PaymentTypeSequence = sequenceType
+	pmtInf.PaymentExecDate = collectionDate
+	pmtInf.PaymentType = localInstrument
+	doc.PaymentInfos = append(doc.PaymentInfos, pmtInf)
+	return &doc.PaymentInfos[len(doc.PaymentInfos)-1]
+}
+
+// findPaymentInfo returns the first group of the sequence type, collection date and local instrument,
+// nil if there is none
+func (doc *DirectDebit) findPaymentInfo(sequenceType string, collectionDate Date, localInstrument string) *DebitPaymentInfo {
+	for i := range doc.PaymentInfos {
+		p := &doc.PaymentInfos[i]
+		if p.PaymentTypeSequence == sequenceType && p.PaymentExecDate == collectionDate && p.PaymentType == localInstrument {
+			return p
+		}
+	}
 	return nil
 }
 
+// PaymentInfo returns the payment information group with the given ID, nil if there is none
+func (doc *DirectDebit) PaymentInfo(paymentInfoID string) *DebitPaymentInfo {
+	for i := range doc.PaymentInfos {
+		if doc.PaymentInfos[i].PaymentInfoID == paymentInfoID {
+			return &doc.PaymentInfos[i]
+		}
+	}
+	return nil
+}
+
+// directDebit has the pain.008.003.02 layout of DirectDebit without its MarshalXML method
+type directDebit DirectDebit
+
+// MarshalXML writes the document in the layout of its schema version,
+// pain.008.001.02 shares the layout of pain.008.003.02
+func (doc *DirectDebit) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	if doc.Version() == Pain008V08 {
+		return e.Encode(newDirectDebitV08(doc))
+	}
+	return e.Encode((*directDebit)(doc))
+}
+
 // Serialize returns the xml document in byte stream
 func (doc *DirectDebit) Serialize() ([]byte, error) {
 	return lib.Serialize(doc)
@@ -146,3 +523,13 @@ func (doc *DirectDebit) Serialize() ([]byte, error) {
 func (doc *DirectDebit) PrettySerialize() ([]byte, error) {
 	return lib.PrettySerialize(doc)
 }
+
+// SerializeTo writes the xml document to w, see also DirectDebitEncoder to write it transaction by transaction
+func (doc *DirectDebit) SerializeTo(w io.Writer) error {
+	return lib.SerializeTo(w, doc)
+}
+
+// PrettySerializeTo writes the indented xml document to w
+func (doc *DirectDebit) PrettySerializeTo(w io.Writer) error {
+	return lib.PrettySerializeTo(w, doc)
+}
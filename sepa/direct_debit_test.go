@@ -0,0 +1,182 @@
+package sepa
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDirectDebitPaymentInfos(t *testing.T) {
+	var sepaDoc = &DirectDebit{}
+	if err := sepaDoc.InitDoc("MSGID", "PMT", "2017-06-07T14:39:33", "2017-06-11", "Emitter Name", "FR1420041010050500013M02606", "BKAUATWW", "DE98ZZZ09999999999", "DE", "some street", "some city"); err != nil {
+		t.Fatal("Expected InitDoc return nil", "got", err)
+	}
+
+	// Bad sequence type
+	if err := sepaDoc.AddTransaction("E2E-0", eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-0", "2017-01-01", "LAST", ""); err == nil {
+		t.Error("Expected AddTransaction return an error for bad sequence type")
+	}
+
+	// Zero and negative amounts
+	for _, amount := range []Amount{{}, eur("-10")} {
+		if err := sepaDoc.AddTransaction("E2E-0", amount, "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-0", "2017-01-01", SequenceFirst, ""); err == nil || !strings.Contains(err.Error(), "is not positive") {
+			t.Error("Expected AddTransaction return an error for amount", amount, "got", err)
+		}
+	}
+
+	// Bad collection date
+	if err := sepaDoc.AddTransaction("E2E-0", eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-0", "2017-01-01", SequenceFirst, "11.06.2017"); err == nil {
+		t.Error("Expected AddTransaction return an error for bad collection date")
+	}
+
+	// Mandate signature date with a time of day
+	if err := sepaDoc.AddTransaction("E2E-0", eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-0", "2017-06-07T14:39:33", SequenceFirst, ""); err == nil {
+		t.Error("Expected AddTransaction return an error for a mandate signature date with a time of day")
+	}
+
+	// Mandate signed after the collection
+	if err := sepaDoc.AddCollection(Collection{ID: "E2E-0", Amount: eur("10"), Currency: "EUR", Debtor: Party{Name: "Debtor"},
+		DebtorAccount: Account{IBAN: "GB29NWBK60161331926819"}, MandateID: "MNDT-0", MandateSignatureDate: day("2017-06-12"),
+		SequenceType: SequenceFirst}); err == nil {
+		t.Error("Expected AddCollection return an error for a mandate signed after the collection date")
+	}
+	if err := sepaDoc.AddCollection(Collection{ID: "E2E-0", Amount: eur("10"), Currency: "EUR", Debtor: Party{Name: "Debtor"},
+		DebtorAccount: Account{IBAN: "GB29NWBK60161331926819"}, MandateID: "MNDT-0", SequenceType: SequenceFirst}); err == nil {
+		t.Error("Expected AddCollection return an error for a missing mandate signature date")
+	}
+
+	TTest := []struct {
+		id             string
+		amount         Amount
+		sequenceType   string
+		collectionDate string
+	}{
+		{"E2E-1", eur("10"), SequenceFirst, ""},
+		{"E2E-2", eur("20.5"), SequenceRecurring, "2017-06-11"},
+		{"E2E-3", eur("30"), SequenceRecurring, "2017-06-11"},
+		{"E2E-4", eur("40.25"), SequenceFirst, "2017-06-11"},
+		{"E2E-5", eur("50"), SequenceRecurring, "2017-06-14"},
+	}
+	for _, transact := range TTest {
+		if err := sepaDoc.AddTransaction(transact.id, transact.amount, "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-"+transact.id, "2017-01-01", transact.sequenceType, transact.collectionDate); err != nil {
+			t.Error("Expected AddTransaction return nil", "got", err)
+		}
+	}
+	if err := sepaDoc.SetLocalInstrument("B2B"); err != nil {
+		t.Error("Expected SetLocalInstrument return nil", "got", err)
+	}
+	if err := sepaDoc.AddTransaction("E2E-6", eur("60"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-6", "2017-01-01", SequenceRecurring, "2017-06-14"); err != nil {
+		t.Error("Expected AddTransaction return nil", "got", err)
+	}
+
+	if sepaDoc.GroupHeaderTransactNo != 6 || sepaDoc.GroupHeaderCtrlSum.Cmp(eur("210.75")) != 0 {
+		t.Error("Expected group header totals 6/210.75", "got", sepaDoc.GroupHeaderTransactNo, sepaDoc.GroupHeaderCtrlSum)
+	}
+	expected := []struct {
+		id              string
+		sequenceType    string
+		collectionDate  string
+		localInstrument string
+		transactNo      int
+		ctrlSum         Amount
+	}{
+		{"PMT", SequenceFirst, "2017-06-11", "CORE", 2, eur("50.25")},
+		{"PMT-2", SequenceRecurring, "2017-06-11", "CORE", 2, eur("50.5")},
+		{"PMT-3", SequenceRecurring, "2017-06-14", "CORE", 1, eur("50")},
+		{"PMT-4", SequenceRecurring, "2017-06-14", "B2B", 1, eur("60")},
+	}
+	if len(sepaDoc.PaymentInfos) != len(expected) {
+		t.Fatal("Expected", len(expected), "payment infos", "got", len(sepaDoc.PaymentInfos))
+	}
+	for i, e := range expected {
+		p := sepaDoc.PaymentInfos[i]
+		if p.PaymentInfoID != e.id || p.PaymentTypeSequence != e.sequenceType || p.PaymentExecDate.String() != e.collectionDate || p.PaymentType != e.localInstrument {
+			t.Error("Expected payment info", e, "got", p.PaymentInfoID, p.PaymentTypeSequence, p.PaymentExecDate, p.PaymentType)
+		}
+		if p.PaymentInfoTransactNo != e.transactNo || p.PaymentInfoCtrlSum.Cmp(e.ctrlSum) != 0 {
+			t.Error("Expected payment info totals", e.transactNo, e.ctrlSum, "got", p.PaymentInfoTransactNo, p.PaymentInfoCtrlSum)
+		}
+	}
+
+	str, err := sepaDoc.Serialize()
+	if err != nil {
+		t.Fatal("Expected xml in []byte, got ", err)
+	}
+	if n := strings.Count(string(str), "<PmtInf>"); n != 4 {
+		t.Error("Expected 4 PmtInf blocks", "got", n)
+	}
+	if !strings.Contains(string(str), "<PmtTpInf><SvcLvl><Cd>SEPA</Cd></SvcLvl><LclInstrm><Cd>CORE</Cd></LclInstrm><SeqTp>RCUR</SeqTp></PmtTpInf><ReqdColltnDt>2017-06-11</ReqdColltnDt>") {
+		t.Error("Expected RCUR payment info in", string(str))
+	}
+
+	// a payment info ID leaving no room for the suffix
+	long := strings.Repeat("P", 35)
+	if err := sepaDoc.InitDoc("MSGID", long, "2017-06-07T14:39:33", "2017-06-11", "Emitter Name", "FR1420041010050500013M02606", "BKAUATWW", "DE98ZZZ09999999999", "DE", "some street", "some city"); err != nil {
+		t.Fatal("Expected InitDoc return nil", "got", err)
+	}
+	for i, sequenceType := range []string{SequenceFirst, SequenceRecurring} {
+		id := "E2E-" + string(rune('1'+i))
+		if err := sepaDoc.AddTransaction(id, eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-"+id, "2017-01-01", sequenceType, ""); err != nil {
+			t.Fatal("Expected AddTransaction return nil", "got", err)
+		}
+	}
+	if id := sepaDoc.PaymentInfos[1].PaymentInfoID; id != long[:33]+"-2" {
+		t.Error("Expected", long[:33]+"-2", "got", id)
+	}
+}
+
+func TestDirectDebitVersions(t *testing.T) {
+	var sepaDoc = &DirectDebit{}
+	if err := sepaDoc.InitDoc("MSGID", "PMT", "2017-06-07T14:39:33", "2017-06-11", "Emitter Name", "FR1420041010050500013M02606", "BKAUATWW", "DE98ZZZ09999999999", "DE", "some street", "some city"); err != nil {
+		t.Fatal("Expected InitDoc return nil", "got", err)
+	}
+	if sepaDoc.Version() != Pain008DKV02 {
+		t.Error("Expected version", Pain008DKV02, "got", sepaDoc.Version())
+	}
+	if err := sepaDoc.AddTransaction("E2E-1", eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-1", "2017-01-01", SequenceFirst, ""); err != nil {
+		t.Error("Expected AddTransaction return nil", "got", err)
+	}
+	if err := sepaDoc.AddTransaction("E2E-2", eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "", "MNDT-2", "2017-01-01", SequenceFirst, ""); err != nil {
+		t.Error("Expected AddTransaction return nil", "got", err)
+	}
+	if err := sepaDoc.SetVersion(Pain001V09); err == nil {
+		t.Error("Expected SetVersion return an error for unsupported version")
+	}
+
+	TTest := []struct {
+		version  string
+		contains []string
+	}{
+		{Pain008DKV02, []string{
+			`xmlns="urn:iso:std:iso:20022:tech:xsd:pain.008.003.02"`,
+			"<CdtrAgt><FinInstnId><BIC>BKAUATWW</BIC></FinInstnId></CdtrAgt>",
+			"<PstlAdr><Ctry>DE</Ctry><AdrLine>some street</AdrLine><AdrLine>some city</AdrLine></PstlAdr>",
+		}},
+		{Pain008V02, []string{
+			`xsi:schemaLocation="urn:iso:std:iso:20022:tech:xsd:pain.008.001.02 pain.008.001.02.xsd"`,
+			"<DbtrAgt><FinInstnId><BIC>BFAUAUWA</BIC></FinInstnId></DbtrAgt>",
+		}},
+		{Pain008V08, []string{
+			`xmlns="urn:iso:std:iso:20022:tech:xsd:pain.008.001.08"`,
+			"<CdtrAgt><FinInstnId><BICFI>BKAUATWW</BICFI></FinInstnId></CdtrAgt>",
+			"<DbtrAgt><FinInstnId><BICFI>BFAUAUWA</BICFI></FinInstnId></DbtrAgt>",
+			"<EndToEndId>E2E-1</EndToEndId>",
+		}},
+	}
+	for _, test := range TTest {
+		if err := sepaDoc.SetVersion(test.version); err != nil {
+			t.Fatal("Expected SetVersion return nil", "got", err)
+		}
+		str, err := sepaDoc.Serialize()
+		if err != nil {
+			t.Fatal("Expected xml in []byte, got ", err)
+		}
+		for _, c := range test.contains {
+			if !strings.Contains(string(str), c) {
+				t.Error("Expected", c, "in", string(str))
+			}
+		}
+		if strings.Count(string(str), "<RmtInf>") != 1 {
+			t.Error("Expected no remittance information for E2E-2", "got", string(str))
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package sepa
+
+import (
+	"encoding/xml"
+)
+
+// directDebitV08 is the pain.008.001.08 layout of a DirectDebit
+type directDebitV08 struct {
+	XMLName                xml.Name              `xml:"Document"`
+	XMLXsiLoc              string                `xml:"xsi:schemaLocation,attr"`
+	XMLNs                  string                `xml:"xmlns,attr"`
+	XMLXsi                 string                `xml:"xmlns:xsi,attr"`
+	GroupHeaderMsgID       string                `xml:"CstmrDrctDbtInitn>GrpHdr>MsgId"`
+	GroupHeaderCreateDate  DateTime              `xml:"CstmrDrctDbtInitn>GrpHdr>CreDtTm"`
+	GroupHeaderTransactNo  int                   `xml:"CstmrDrctDbtInitn>GrpHdr>NbOfTxs"`
+	GroupHeaderCtrlSum     Amount                `xml:"CstmrDrctDbtInitn>GrpHdr>CtrlSum"`
+	GroupHeaderEmitterName string                `xml:"CstmrDrctDbtInitn>GrpHdr>InitgPty>Nm"`
+	GroupHeaderEmitterID   *partyIDXML           `xml:"CstmrDrctDbtInitn>GrpHdr>InitgPty>Id"`
+	PaymentInfos           []debitPaymentInfoV08 `xml:"CstmrDrctDbtInitn>PmtInf"`
+}
+
+// debitPaymentInfoV08 is the pain.008.001.08 layout of a DebitPaymentInfo
+type debitPaymentInfoV08 struct {
+	PaymentInfoID               string                `xml:"PmtInfId"`
+	PaymentInfoMethod           string                `xml:"PmtMtd"`
+	PaymentBatch                string                `xml:"BtchBookg"`
+	PaymentInfoTransactNo       int                   `xml:"NbOfTxs"`
+	PaymentInfoCtrlSum          Amount                `xml:"CtrlSum"`
+	PaymentTypeInfo             string                `xml:"PmtTpInf>SvcLvl>Cd"`
+	PaymentType                 string                `xml:"PmtTpInf>LclInstrm>Cd"`
+	PaymentTypeSequence         string                `xml:"PmtTpInf>SeqTp"`
+	PaymentExecDate             Date                  `xml:"ReqdColltnDt"`
+	PaymentEmitterName          string                `xml:"Cdtr>Nm"`
+	PaymentEmitterPostalAddress *postalAddress24      `xml:"Cdtr>PstlAdr"`
+	PaymentEmitterCreditorID    *partyIDXML           `xml:"Cdtr>Id"`
+	PaymentEmitterIBAN          string                `xml:"CdtrAcct>Id>IBAN"`
+	PaymentEmitterCurrency      string                `xml:"CdtrAcct>Ccy,omitempty"`
+	PaymentEmitterAgent         *agentBICFI           `xml:"CdtrAgt"`
+	PaymentEmitterID            string                `xml:"CdtrSchmeId>Id>PrvtId>Othr>Id"`
+	PaymentEmitterProprietary   string                `xml:"CdtrSchmeId>Id>PrvtId>Othr>SchmeNm>Prtry"`
+	PaymentTransactions         []debitTransactionV08 `xml:"DrctDbtTxInf"`
+}
+
+// debitTransactionV08 is the pain.008.001.08 layout of a DebitTransaction
+type debitTransactionV08 struct {
+	TransactIDe2e                string           `xml:"PmtId>EndToEndId"`
+	TransactAmount               TAmount          `xml:"InstdAmt"`
+	TransactMandantId            string           `xml:"DrctDbtTx>MndtRltdInf>MndtId"`
+	TransactMandantSignatureDate Date             `xml:"DrctDbtTx>MndtRltdInf>DtOfSgntr"`
+	TransactCreditorSchemeID     *CreditorScheme  `xml:"DrctDbtTx>CdtrSchmeId"`
+	TransactDebtorAgent          *agentBICFI      `xml:"DbtrAgt"`
+	TransactDebtorName           string           `xml:"Dbtr>Nm"`
+	TransactDebtorPostalAddress  *postalAddress24 `xml:"Dbtr>PstlAdr"`
+	TransactDebtorID             *partyIDXML      `xml:"Dbtr>Id"`
+	TransactDebtorIBAN           string           `xml:"DbtrAcct>Id>IBAN"`
+	TransactDebtorCurrency       string           `xml:"DbtrAcct>Ccy,omitempty"`
+	TransactMotif                *RemittanceInfo  `xml:"RmtInf"`
+}
+
+// newDirectDebitV08 maps a DirectDebit onto the pain.008.001.08 layout
+func newDirectDebitV08(doc *DirectDebit) *directDebitV08 {
+	v08 := &directDebitV08{
+		XMLXsiLoc:              doc.XMLXsiLoc,
+		XMLNs:                  doc.XMLNs,
+		XMLXsi:                 doc.XMLXsi,
+		GroupHeaderMsgID:       doc.GroupHeaderMsgID,
+		GroupHeaderCreateDate:  doc.GroupHeaderCreateDate,
+		GroupHeaderTransactNo:  doc.GroupHeaderTransactNo,
+		GroupHeaderCtrlSum:     doc.GroupHeaderCtrlSum,
+		GroupHeaderEmitterName: doc.GroupHeaderEmitterName,
+		GroupHeaderEmitterID:   newPartyIDXMLOf(doc.GroupHeaderEmitterID),
+	}
+	for _, p := range doc.PaymentInfos {
+		pmtInf := debitPaymentInfoV08{
+			PaymentInfoID:               p.PaymentInfoID,
+			PaymentInfoMethod:           p.PaymentInfoMethod,
+			PaymentBatch:                p.PaymentBatch,
+			PaymentInfoTransactNo:       p.PaymentInfoTransactNo,
+			PaymentInfoCtrlSum:          p.PaymentInfoCtrlSum,
+			PaymentTypeInfo:             p.PaymentTypeInfo,
+			PaymentType:                 p.PaymentType,
+			PaymentTypeSequence:         p.PaymentTypeSequence,
+			PaymentExecDate:             p.PaymentExecDate,
+			PaymentEmitterName:          p.PaymentEmitterName,
+			PaymentEmitterPostalAddress: newPostalAddress24Of(p.PaymentEmitterPostalAddress),
+			PaymentEmitterCreditorID:    newPartyIDXMLOf(p.PaymentEmitterCreditorID),
+			PaymentEmitterIBAN:          p.PaymentEmitterIBAN,
+			PaymentEmitterCurrency:      p.PaymentEmitterCurrency,
+			PaymentEmitterAgent:         newAgentBICFI(p.PaymentEmitterAgent),
+			PaymentEmitterID:            p.PaymentEmitterID,
+			PaymentEmitterProprietary:   p.PaymentEmitterProprietary,
+		}
+		for _, t := range p.PaymentTransactions {
+			pmtInf.PaymentTransactions = append(pmtInf.PaymentTransactions, newDebitTransactionV08(t))
+		}
+		v08.PaymentInfos = append(v08.PaymentInfos, pmtInf)
+	}
+	return v08
+}
+
+// directDebit maps the pain.008.001.08 layout back onto a DirectDebit
+func (v08 *directDebitV08) directDebit() *DirectDebit {
+	doc := &DirectDebit{
+		GroupHeaderMsgID:       v08.GroupHeaderMsgID,
+		GroupHeaderCreateDate:  v08.GroupHeaderCreateDate,
+		GroupHeaderTransactNo:  v08.GroupHeaderTransactNo,
+		GroupHeaderCtrlSum:     v08.GroupHeaderCtrlSum,
+		GroupHeaderEmitterName: v08.GroupHeaderEmitterName,
+		GroupHeaderEmitterID:   v08.GroupHeaderEmitterID.partyIDRef(),
+	}
+	for _, p := range v08.PaymentInfos {
+		pmtInf := p.debitPaymentInfo()
+		for _, t := range p.PaymentTransactions {
+			pmtInf.PaymentTransactions = append(pmtInf.PaymentTransactions, t.debitTransaction())
+		}
+		doc.PaymentInfos = append(doc.PaymentInfos, pmtInf)
+	}
+	return doc
+}
+
+// debitPaymentInfo maps the pain.008.001.08 layout of a group back onto a DebitPaymentInfo without its transactions
+func (p *debitPaymentInfoV08) debitPaymentInfo() DebitPaymentInfo {
+	return DebitPaymentInfo{
+		PaymentInfoID:               p.PaymentInfoID,
+		PaymentInfoMethod:           p.PaymentInfoMethod,
+		PaymentBatch:                p.PaymentBatch,
+		PaymentInfoTransactNo:       p.PaymentInfoTransactNo,
+		PaymentInfoCtrlSum:          p.PaymentInfoCtrlSum,
+		PaymentTypeInfo:             p.PaymentTypeInfo,
+		PaymentType:                 p.PaymentType,
+		PaymentTypeSequence:         p.PaymentTypeSequence,
+		PaymentExecDate:             p.PaymentExecDate,
+		PaymentEmitterName:          p.PaymentEmitterName,
+		PaymentEmitterPostalAddress: p.PaymentEmitterPostalAddress.postalAddressRef(),
+		PaymentEmitterCreditorID:    p.PaymentEmitterCreditorID.partyIDRef(),
+		PaymentEmitterIBAN:          p.PaymentEmitterIBAN,
+		PaymentEmitterCurrency:      p.PaymentEmitterCurrency,
+		PaymentEmitterAgent:         p.PaymentEmitterAgent.agent(),
+		PaymentEmitterID:            p.PaymentEmitterID,
+		PaymentEmitterProprietary:   p.PaymentEmitterProprietary,
+	}
+}
+
+// newDebitTransactionV08 maps a DebitTransaction onto the pain.008.001.08 layout
+func newDebitTransactionV08(t DebitTransaction) debitTransactionV08 {
+	return debitTransactionV08{
+		TransactIDe2e:                t.TransactIDe2e,
+		TransactAmount:               t.TransactAmount,
+		TransactMandantId:            t.TransactMandantId,
+		TransactMandantSignatureDate: t.TransactMandantSignatureDate,
+		TransactCreditorSchemeID:     t.TransactCreditorSchemeID,
+		TransactDebtorAgent:          newAgentBICFI(t.TransactDebtorAgent),
+		TransactDebtorName:           t.TransactDebtorName,
+		TransactDebtorPostalAddress:  newPostalAddress24Of(t.TransactDebtorPostalAddress),
+		TransactDebtorID:             newPartyIDXMLOf(t.TransactDebtorID),
+		TransactDebtorIBAN:           t.TransactDebtorIBAN,
+		TransactDebtorCurrency:       t.TransactDebtorCurrency,
+		TransactMotif:                t.TransactMotif,
+	}
+}
+
+// debitTransaction maps the pain.008.001.08 layout back onto a DebitTransaction
+func (v08 debitTransactionV08) debitTransaction() DebitTransaction {
+	return DebitTransaction{
+		TransactIDe2e:                v08.TransactIDe2e,
+		TransactAmount:               v08.TransactAmount,
+		TransactMandantId:            v08.TransactMandantId,
+		TransactMandantSignatureDate: v08.TransactMandantSignatureDate,
+		TransactCreditorSchemeID:     v08.TransactCreditorSchemeID,
+		TransactDebtorAgent:          v08.TransactDebtorAgent.agent(),
+		TransactDebtorName:           v08.TransactDebtorName,
+		TransactDebtorPostalAddress:  v08.TransactDebtorPostalAddress.postalAddressRef(),
+		TransactDebtorID:             v08.TransactDebtorID.partyIDRef(),
+		TransactDebtorIBAN:           v08.TransactDebtorIBAN,
+		TransactDebtorCurrency:       v08.TransactDebtorCurrency,
+		TransactMotif:                v08.TransactMotif,
+	}
+}
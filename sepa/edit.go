@@ -0,0 +1,220 @@
+package sepa
+
+import (
+	"errors"
+	"sort"
+)
+
+// Recalculate sets the transaction numbers and control sums of every payment information group and of the group
+// header from the transactions, after PaymentTransactions were edited by hand
+func (doc *CreditTransfer) Recalculate() error {
+	groups := doc.PaymentInfos
+	count, total, err := recount(len(groups), func(g int) int { return len(groups[g].PaymentTransactions) },
+		func(g int, t int) Amount { return groups[g].PaymentTransactions[t].TransactAmount.Amount },
+		func(g int, transactNo int, ctrlSum Amount) {
+			groups[g].PaymentInfoTransactNo, groups[g].PaymentInfoCtrlSum = transactNo, ctrlSum
+		})
+	if err != nil {
+		return err
+	}
+	doc.GroupHeaderTransactNo, doc.GroupHeaderCtrlSum = count, total
+	return nil
+}
+
+// transaction returns the index of the group and of the transaction with the given end to end ID, -1 if there is none
+func (doc *CreditTransfer) transaction(endToEndID string) (int, int) {
+	groups := doc.PaymentInfos
+	return locate(len(groups), func(g int) int { return len(groups[g].PaymentTransactions) },
+		func(g int, t int) string { return groups[g].PaymentTransactions[t].TransactIDe2e }, endToEndID)
+}
+
+// RemoveTransaction removes the transaction with the given end to end ID and adjust the transaction numbers and the
+// control sums. A group left empty is removed, the schemas require at least one transaction per group.
+func (doc *CreditTransfer) RemoveTransaction(endToEndID string) error {
+	i, j := doc.transaction(endToEndID)
+	if i < 0 {
+		return errors.New("unknown end to end ID " + endToEndID)
+	}
+	pmtInf := &doc.PaymentInfos[i]
+	pmtInf.PaymentTransactions = append(pmtInf.PaymentTransactions[:j], pmtInf.PaymentTransactions[j+1:]...)
+	if len(pmtInf.PaymentTransactions) == 0 {
+		doc.PaymentInfos = append(doc.PaymentInfos[:i], doc.PaymentInfos[i+1:]...)
+	}
+	return doc.Recalculate()
+}
+
+// ReplaceTransaction replaces the transaction with the given end to end ID by a transfer, at the same place,
+// and adjust the control sums
+func (doc *CreditTransfer) ReplaceTransaction(endToEndID string, t Transfer) error {
+	i, j := doc.transaction(endToEndID)
+	if i < 0 {
+		return errors.New("unknown end to end ID " + endToEndID)
+	}
+	if t.ID != endToEndID {
+		if k, _ := doc.transaction(t.ID); k >= 0 {
+			return errors.New("duplicate end to end ID " + t.ID)
+		}
+	}
+	transaction, err := newCreditTransaction(t, doc.text())
+	if err != nil {
+		return err
+	}
+	pmtInf := &doc.PaymentInfos[i]
+	if err := checkReplacedSum(doc.GroupHeaderCtrlSum, pmtInf.PaymentTransactions[j].TransactAmount, transaction.TransactAmount); err != nil {
+		return err
+	}
+	pmtInf.PaymentTransactions[j] = transaction
+	return doc.Recalculate()
+}
+
+// SortTransactions sorts the transactions of every payment information group, keeping the order of equal ones.
+// Transactions stay in their group, so the transaction numbers and control sums don't change.
+func (doc *CreditTransfer) SortTransactions(less func(a, b CreditTransaction) bool) {
+	groups := doc.PaymentInfos
+	sortGroups(len(groups), func(g int) interface{} { return groups[g].PaymentTransactions }, func(g int, a int, b int) bool {
+		return less(groups[g].PaymentTransactions[a], groups[g].PaymentTransactions[b])
+	})
+}
+
+// Recalculate sets the transaction numbers and control sums of every payment information group and of the group
+// header from the transactions, after PaymentTransactions were edited by hand
+func (doc *DirectDebit) Recalculate() error {
+	groups := doc.PaymentInfos
+	count, total, err := recount(len(groups), func(g int) int { return len(groups[g].PaymentTransactions) },
+		func(g int, t int) Amount { return groups[g].PaymentTransactions[t].TransactAmount.Amount },
+		func(g int, transactNo int, ctrlSum Amount) {
+			groups[g].PaymentInfoTransactNo, groups[g].PaymentInfoCtrlSum = transactNo, ctrlSum
+		})
+	if err != nil {
+		return err
+	}
+	doc.GroupHeaderTransactNo, doc.GroupHeaderCtrlSum = count, total
+	return nil
+}
+
+// transaction returns the index of the group and of the transaction with the given end to end ID, -1 if there is none
+func (doc *DirectDebit) transaction(endToEndID string) (int, int) {
+	groups := doc.PaymentInfos
+	return locate(len(groups), func(g int) int { return len(groups[g].PaymentTransactions) },
+		func(g int, t int) string { return groups[g].PaymentTransactions[t].TransactIDe2e }, endToEndID)
+}
+
+// removeTransaction removes a transaction, and its group when it is left empty
+func (doc *DirectDebit) removeTransaction(i int, j int) {
+	pmtInf := &doc.PaymentInfos[i]
+	pmtInf.PaymentTransactions = append(pmtInf.PaymentTransactions[:j], pmtInf.PaymentTransactions[j+1:]...)
+	if len(pmtInf.PaymentTransactions) == 0 {
+		doc.PaymentInfos = append(doc.PaymentInfos[:i], doc.PaymentInfos[i+1:]...)
+	}
+}
+
+// RemoveTransaction removes the transaction with the given end to end ID and adjust the transaction numbers and the
+// control sums. A group left empty is removed, AddTransaction creates it again when needed.
+func (doc *DirectDebit) RemoveTransaction(endToEndID string) error {
+	i, j := doc.transaction(endToEndID)
+	if i < 0 {
+		return errors.New("unknown end to end ID " + endToEndID)
+	}
+	doc.removeTransaction(i, j)
+	return doc.Recalculate()
+}
+
+// ReplaceTransaction replaces the transaction with the given end to end ID by a direct debit and adjust the control
+// sums. It keeps its place when the sequence type and collection date don't change, otherwise it moves to the group
+// matching them, with the local instrument of its former group.
+func (doc *DirectDebit) ReplaceTransaction(endToEndID string, d Collection) error {
+	i, j := doc.transaction(endToEndID)
+	if i < 0 {
+		return errors.New("unknown end to end ID " + endToEndID)
+	}
+	if d.ID != endToEndID {
+		if k, _ := doc.transaction(d.ID); k >= 0 {
+			return errors.New("duplicate end to end ID " + d.ID)
+		}
+	}
+	d, err := doc.checkCollection(d)
+	if err != nil {
+		return err
+	}
+	transaction := newDebitTransaction(d)
+	pmtInf := &doc.PaymentInfos[i]
+	if err := checkReplacedSum(doc.GroupHeaderCtrlSum, pmtInf.PaymentTransactions[j].TransactAmount, transaction.TransactAmount); err != nil {
+		return err
+	}
+	if pmtInf.PaymentTypeSequence == d.SequenceType && pmtInf.PaymentExecDate == d.CollectionDate {
+		pmtInf.PaymentTransactions[j] = transaction
+		return doc.Recalculate()
+	}
+	localInstrument := pmtInf.PaymentType
+	doc.removeTransaction(i, j)
+	pmtInf = doc.paymentInfoFor(d.SequenceType, d.CollectionDate, localInstrument)
+	pmtInf.PaymentTransactions = append(pmtInf.PaymentTransactions, transaction)
+	return doc.Recalculate()
+}
+
+// SortTransactions sorts the transactions of every payment information group, keeping the order of equal ones.
+// Transactions stay in their group, so the transaction numbers and control sums don't change.
+func (doc *DirectDebit) SortTransactions(less func(a, b DebitTransaction) bool) {
+	groups := doc.PaymentInfos
+	sortGroups(len(groups), func(g int) interface{} { return groups[g].PaymentTransactions }, func(g int, a int, b int) bool {
+		return less(groups[g].PaymentTransactions[a], groups[g].PaymentTransactions[b])
+	})
+}
+
+// checkReplacedSum returns an error if the control sum overflows once a transaction amount is replaced,
+// so that a replacement is refused before the document is changed
+func checkReplacedSum(ctrlSum Amount, old TAmount, replacement TAmount) error {
+	sum, err := ctrlSum.Sub(old.Amount)
+	if err != nil {
+		return err
+	}
+	_, err = sum.Add(replacement.Amount)
+	return err
+}
+
+// recount sets the transaction number and control sum of n groups from the number of their transactions and the
+// amount of each, and returns the totals of the group header. Nothing is set if a sum overflows.
+func recount(n int, transactNo func(g int) int, amount func(g int, t int) Amount,
+	set func(g int, transactNo int, ctrlSum Amount)) (int, Amount, error) {
+	sums := make([]Amount, n)
+	var total Amount
+	count := 0
+	for g := 0; g < n; g++ {
+		for t := 0; t < transactNo(g); t++ {
+			var err error
+			if sums[g], err = sums[g].Add(amount(g, t)); err != nil {
+				return 0, Amount{}, err
+			}
+			if total, err = total.Add(amount(g, t)); err != nil {
+				return 0, Amount{}, err
+			}
+		}
+		count += transactNo(g)
+	}
+	for g := 0; g < n; g++ {
+		set(g, transactNo(g), sums[g])
+	}
+	return count, total, nil
+}
+
+// locate returns the index of the group and of the transaction with the given end to end ID among n groups,
+// -1 if there is none
+func locate(n int, transactNo func(g int) int, endToEndID func(g int, t int) string, id string) (int, int) {
+	for g := 0; g < n; g++ {
+		for t := 0; t < transactNo(g); t++ {
+			if endToEndID(g, t) == id {
+				return g, t
+			}
+		}
+	}
+	return -1, -1
+}
+
+// sortGroups sorts the transactions of n groups, keeping the order of equal ones
+func sortGroups(n int, transactions func(g int) interface{}, less func(g int, a int, b int) bool) {
+	for g := 0; g < n; g++ {
+		sort.SliceStable(transactions(g), func(a, b int) bool {
+			return less(g, a, b)
+		})
+	}
+}
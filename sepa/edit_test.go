@@ -0,0 +1,156 @@
+package sepa
+
+import (
+	"testing"
+)
+
+func TestCreditTransferEdit(t *testing.T) {
+	doc, err := NewCreditTransfer(CreditTransferConfig{MsgID: "MSGID", PaymentInfoID: "PMT-1", CreationDate: at("2017-05-01T22:45:03"),
+		ExecutionDate: day("2017-05-03"), Initiator: testEmitter, DebtorAccount: testEmitterAccount})
+	if err != nil {
+		t.Fatal("Expected NewCreditTransfer return nil", "got", err)
+	}
+	for _, id := range []string{"F1", "F2"} {
+		if err := doc.AddTransaction(id, eur("10"), "EUR", "Creditor "+id, "GB29NWBK60161331926819", "", "Invoice"); err != nil {
+			t.Fatal("Expected AddTransaction return nil", "got", err)
+		}
+	}
+	if err := doc.AddPaymentInfo("PMT-2", "2017-05-04", "Emitter Name", "AT611904300234573201", "", "DE", "some street", "some city"); err != nil {
+		t.Fatal("Expected AddPaymentInfo return nil", "got", err)
+	}
+	for _, id := range []string{"F4", "F3"} {
+		if err := doc.AddTransaction(id, eur("20"), "EUR", "Creditor "+id, "GB29NWBK60161331926819", "", "Invoice"); err != nil {
+			t.Fatal("Expected AddTransaction return nil", "got", err)
+		}
+	}
+
+	// remove
+	if err := doc.RemoveTransaction("F9"); err == nil {
+		t.Error("Expected RemoveTransaction return an error for an unknown end to end ID")
+	}
+	if err := doc.RemoveTransaction("F1"); err != nil {
+		t.Fatal("Expected RemoveTransaction return nil", "got", err)
+	}
+	if doc.GroupHeaderTransactNo != 3 || doc.GroupHeaderCtrlSum != eur("50") {
+		t.Error("Expected 3 transactions for 50.00", "got", doc.GroupHeaderTransactNo, doc.GroupHeaderCtrlSum)
+	}
+	if p := doc.PaymentInfos[0]; p.PaymentInfoTransactNo != 1 || p.PaymentInfoCtrlSum != eur("10") || p.PaymentTransactions[0].TransactIDe2e != "F2" {
+		t.Error("Expected F2 left in PMT-1", "got", p.PaymentInfoTransactNo, p.PaymentInfoCtrlSum, p.PaymentTransactions)
+	}
+
+	// replace
+	replacement := Transfer{ID: "F5", Amount: eur("12.5"), Currency: "EUR", Creditor: Party{Name: "Creditor F5"},
+		CreditorAccount: Account{IBAN: "GB29NWBK60161331926819"}, Description: "Invoice"}
+	if err := doc.ReplaceTransaction("F9", replacement); err == nil {
+		t.Error("Expected ReplaceTransaction return an error for an unknown end to end ID")
+	}
+	if err := doc.ReplaceTransaction("F2", Transfer{ID: "F3", Amount: eur("1"), Currency: "EUR", Creditor: Party{Name: "Creditor"},
+		CreditorAccount: Account{IBAN: "GB29NWBK60161331926819"}}); err == nil {
+		t.Error("Expected ReplaceTransaction return an error for a duplicate end to end ID")
+	}
+	if err := doc.ReplaceTransaction("F2", Transfer{ID: "F2", Amount: eur("1"), Currency: "EUR", Creditor: Party{Name: "Creditor"},
+		CreditorAccount: Account{IBAN: "Creditor"}}); err == nil {
+		t.Error("Expected ReplaceTransaction return an error for a bad IBAN")
+	}
+	if err := doc.ReplaceTransaction("F2", replacement); err != nil {
+		t.Fatal("Expected ReplaceTransaction return nil", "got", err)
+	}
+	if doc.GroupHeaderTransactNo != 3 || doc.GroupHeaderCtrlSum != eur("52.5") || doc.PaymentInfos[0].PaymentInfoCtrlSum != eur("12.5") {
+		t.Error("Expected 3 transactions for 52.50", "got", doc.GroupHeaderTransactNo, doc.GroupHeaderCtrlSum)
+	}
+	if tx := doc.PaymentInfos[0].PaymentTransactions[0]; tx.TransactIDe2e != "F5" || tx.TransactCreditorName != "Creditor F5" {
+		t.Error("Expected F5 in place of F2", "got", tx)
+	}
+
+	// sort
+	doc.SortTransactions(func(a, b CreditTransaction) bool { return a.TransactIDe2e < b.TransactIDe2e })
+	if ids := doc.PaymentInfos[1].PaymentTransactions; ids[0].TransactIDe2e != "F3" || ids[1].TransactIDe2e != "F4" {
+		t.Error("Expected F3 before F4", "got", ids)
+	}
+
+	// edited by hand
+	doc.PaymentInfos[1].PaymentTransactions = doc.PaymentInfos[1].PaymentTransactions[:1]
+	if err := doc.Recalculate(); err != nil {
+		t.Fatal("Expected Recalculate return nil", "got", err)
+	}
+	if doc.GroupHeaderTransactNo != 2 || doc.GroupHeaderCtrlSum != eur("32.5") || doc.PaymentInfos[1].PaymentInfoTransactNo != 1 {
+		t.Error("Expected 2 transactions for 32.50", "got", doc.GroupHeaderTransactNo, doc.GroupHeaderCtrlSum)
+	}
+
+	// a group left empty is removed
+	if err := doc.RemoveTransaction("F5"); err != nil {
+		t.Fatal("Expected RemoveTransaction return nil", "got", err)
+	}
+	if len(doc.PaymentInfos) != 1 || doc.PaymentInfos[0].PaymentInfoID != "PMT-2" || doc.GroupHeaderTransactNo != 1 || doc.GroupHeaderCtrlSum != eur("20") {
+		t.Error("Expected PMT-1 removed", "got", doc.PaymentInfos)
+	}
+}
+func TestDirectDebitEdit(t *testing.T) {
+	doc, err := NewDirectDebit(DirectDebitConfig{MsgID: "MSGID", PaymentInfoID: "PMT", CreationDate: at("2017-06-07T14:39:33"),
+		CollectionDate: day("2017-06-11"), Initiator: testEmitter, CreditorAccount: testEmitterAccount, CreditorSchemeID: "DE98ZZZ09999999999"})
+	if err != nil {
+		t.Fatal("Expected NewDirectDebit return nil", "got", err)
+	}
+	TTest := []struct {
+		id           string
+		amount       Amount
+		sequenceType string
+	}{
+		{"E2E-1", eur("10"), SequenceFirst},
+		{"E2E-2", eur("20"), SequenceRecurring},
+		{"E2E-3", eur("30"), SequenceRecurring},
+	}
+	for _, transact := range TTest {
+		if err := doc.AddTransaction(transact.id, transact.amount, "EUR", "Debtor", "GB29NWBK60161331926819", "", "Invoice", "MNDT-"+transact.id, "2017-01-01", transact.sequenceType, ""); err != nil {
+			t.Fatal("Expected AddTransaction return nil", "got", err)
+		}
+	}
+
+	// removing the last transaction of a group removes the group
+	if err := doc.RemoveTransaction("E2E-1"); err != nil {
+		t.Fatal("Expected RemoveTransaction return nil", "got", err)
+	}
+	if len(doc.PaymentInfos) != 1 || doc.PaymentInfos[0].PaymentInfoID != "PMT-2" || doc.GroupHeaderTransactNo != 2 || doc.GroupHeaderCtrlSum != eur("50") {
+		t.Error("Expected PMT-2 alone with 2 transactions for 50.00", "got", doc.PaymentInfos, doc.GroupHeaderCtrlSum)
+	}
+
+	// a replacement of another sequence type moves to its group, which gets a free payment info ID
+	replacement := Collection{ID: "E2E-4", Amount: eur("40"), Currency: "EUR", Debtor: Party{Name: "Debtor"},
+		DebtorAccount: Account{IBAN: "GB29NWBK60161331926819"}, MandateID: "MNDT-4", MandateSignatureDate: day("2017-01-01"),
+		SequenceType: SequenceFinal}
+	if err := doc.ReplaceTransaction("E2E-2", Collection{ID: "E2E-3"}); err == nil {
+		t.Error("Expected ReplaceTransaction return an error for a duplicate end to end ID")
+	}
+	if err := doc.ReplaceTransaction("E2E-2", replacement); err != nil {
+		t.Fatal("Expected ReplaceTransaction return nil", "got", err)
+	}
+	if len(doc.PaymentInfos) != 2 || doc.PaymentInfos[1].PaymentInfoID != "PMT-3" || doc.PaymentInfos[1].PaymentTypeSequence != SequenceFinal {
+		t.Error("Expected E2E-4 moved to a new FNAL group PMT-3", "got", doc.PaymentInfos)
+	}
+	if doc.GroupHeaderTransactNo != 2 || doc.GroupHeaderCtrlSum != eur("70") || doc.PaymentInfos[0].PaymentInfoCtrlSum != eur("30") {
+		t.Error("Expected 2 transactions for 70.00", "got", doc.GroupHeaderTransactNo, doc.GroupHeaderCtrlSum)
+	}
+
+	// a replacement of the same sequence type and collection date keeps its place
+	replacement.ID, replacement.Amount = "E2E-5", eur("5")
+	if err := doc.ReplaceTransaction("E2E-4", replacement); err != nil {
+		t.Fatal("Expected ReplaceTransaction return nil", "got", err)
+	}
+	if tx := doc.PaymentInfos[1].PaymentTransactions; len(tx) != 1 || tx[0].TransactIDe2e != "E2E-5" || doc.GroupHeaderCtrlSum != eur("35") {
+		t.Error("Expected E2E-5 in place of E2E-4", "got", tx, doc.GroupHeaderCtrlSum)
+	}
+
+	// sort
+	if err := doc.AddTransaction("E2E-0", eur("1"), "EUR", "Debtor", "GB29NWBK60161331926819", "", "Invoice", "MNDT-0", "2017-01-01", SequenceRecurring, ""); err != nil {
+		t.Fatal("Expected AddTransaction return nil", "got", err)
+	}
+	doc.SortTransactions(func(a, b DebitTransaction) bool {
+		return a.TransactAmount.Amount.Minor() < b.TransactAmount.Amount.Minor()
+	})
+	if tx := doc.PaymentInfos[0].PaymentTransactions; tx[0].TransactIDe2e != "E2E-0" || tx[1].TransactIDe2e != "E2E-3" {
+		t.Error("Expected E2E-0 before E2E-3", "got", tx)
+	}
+	if doc.GroupHeaderTransactNo != 3 || doc.GroupHeaderCtrlSum != eur("36") {
+		t.Error("Expected 3 transactions for 36.00", "got", doc.GroupHeaderTransactNo, doc.GroupHeaderCtrlSum)
+	}
+}
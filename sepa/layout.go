@@ -0,0 +1,195 @@
+package sepa
+
+// agentBICFI is the BranchAndFinancialInstitutionIdentification5/6 layout of a BIC used from
+// pain.001.001.09 and pain.008.001.08 on
+type agentBICFI struct {
+	BIC   string   `xml:"FinInstnId>BICFI,omitempty"`
+	Other *otherID `xml:"FinInstnId>Othr"`
+}
+
+// newAgentBICFI maps an Agent onto the BICFI layout, nil for the zero value
+func newAgentBICFI(a Agent) *agentBICFI {
+	switch a.BIC {
+	case "":
+		return nil
+	case NotProvided:
+		return &agentBICFI{Other: &otherID{ID: NotProvided}}
+	}
+	return &agentBICFI{BIC: a.BIC}
+}
+
+// agent maps the BICFI layout back onto an Agent
+func (a *agentBICFI) agent() Agent {
+	switch {
+	case a == nil:
+		return Agent{}
+	case a.BIC != "":
+		return Agent{BIC: a.BIC}
+	case a.Other != nil:
+		return Agent{BIC: a.Other.ID}
+	}
+	return Agent{}
+}
+
+// postalAddress24 is the PostalAddress24 layout of a PostalAddress, where every element is optional
+type postalAddress24 struct {
+	StreetName     string   `xml:"StrtNm,omitempty"`
+	BuildingNumber string   `xml:"BldgNb,omitempty"`
+	PostCode       string   `xml:"PstCd,omitempty"`
+	TownName       string   `xml:"TwnNm,omitempty"`
+	Country        string   `xml:"Ctry,omitempty"`
+	AddressLines   []string `xml:"AdrLine"`
+}
+
+// newPostalAddress24 maps a PostalAddress onto the PostalAddress24 layout, nil if it is empty
+func newPostalAddress24(a PostalAddress) *postalAddress24 {
+	if a.isEmpty() {
+		return nil
+	}
+	p := postalAddress24(a)
+	return &p
+}
+
+// postalAddress maps the PostalAddress24 layout back onto a PostalAddress
+func (p *postalAddress24) postalAddress() PostalAddress {
+	if p == nil {
+		return PostalAddress{}
+	}
+	return PostalAddress(*p)
+}
+
+// newPostalAddress24Of maps an optional PostalAddress onto the PostalAddress24 layout
+func newPostalAddress24Of(a *PostalAddress) *postalAddress24 {
+	if a == nil {
+		return nil
+	}
+	return newPostalAddress24(*a)
+}
+
+// postalAddressRef maps the PostalAddress24 layout back onto an optional PostalAddress
+func (p *postalAddress24) postalAddressRef() *PostalAddress {
+	if p == nil {
+		return nil
+	}
+	a := p.postalAddress()
+	return &a
+}
+
+// partyIDXML is the Party6/Party11 layout (pain.001.001.03, pain.008.001.02) and the Party38 layout
+// (pain.001.001.09, pain.008.001.08) of a PartyID, they only differ in the BIC and LEI of an organisation
+type partyIDXML struct {
+	Organisation *organisationIDXML `xml:"OrgId"`
+	Private      *privateIDXML      `xml:"PrvtId"`
+}
+
+type organisationIDXML struct {
+	BICOrBEI string       `xml:"BICOrBEI,omitempty"`
+	AnyBIC   string       `xml:"AnyBIC,omitempty"`
+	LEI      string       `xml:"LEI,omitempty"`
+	Other    []otherIDXML `xml:"Othr"`
+}
+
+type privateIDXML struct {
+	Birth *birthXML    `xml:"DtAndPlcOfBirth"`
+	Other []otherIDXML `xml:"Othr"`
+}
+
+type birthXML struct {
+	BirthDate       Date   `xml:"BirthDt"`
+	ProvinceOfBirth string `xml:"PrvcOfBirth,omitempty"`
+	CityOfBirth     string `xml:"CityOfBirth"`
+	CountryOfBirth  string `xml:"CtryOfBirth"`
+}
+
+type otherIDXML struct {
+	ID         string         `xml:"Id"`
+	SchemeName *schemeNameXML `xml:"SchmeNm"`
+	Issuer     string         `xml:"Issr,omitempty"`
+}
+
+type schemeNameXML struct {
+	Code        string `xml:"Cd,omitempty"`
+	Proprietary string `xml:"Prtry,omitempty"`
+}
+
+// newPartyIDXML maps a PartyID onto the layout of pain.001.001.09 and pain.008.001.08 if anyBIC is set,
+// onto the one of the older versions otherwise
+func newPartyIDXML(id PartyID, anyBIC bool) *partyIDXML {
+	v := &partyIDXML{}
+	if o := id.Organisation; o != nil {
+		v.Organisation = &organisationIDXML{Other: newOtherIDsXML(o.Other)}
+		if anyBIC {
+			v.Organisation.AnyBIC, v.Organisation.LEI = o.AnyBIC, o.LEI
+		} else {
+			v.Organisation.BICOrBEI = o.AnyBIC
+		}
+	}
+	if p := id.Private; p != nil {
+		v.Private = &privateIDXML{Other: newOtherIDsXML(p.Other)}
+		if !p.BirthDate.IsZero() {
+			v.Private.Birth = &birthXML{BirthDate: p.BirthDate, ProvinceOfBirth: p.ProvinceOfBirth,
+				CityOfBirth: p.CityOfBirth, CountryOfBirth: p.CountryOfBirth}
+		}
+	}
+	return v
+}
+
+// newPartyIDXMLOf maps an optional PartyID onto the layout of pain.001.001.09 and pain.008.001.08
+func newPartyIDXMLOf(id *PartyID) *partyIDXML {
+	if id == nil {
+		return nil
+	}
+	return newPartyIDXML(*id, true)
+}
+
+func newOtherIDsXML(ids []OtherID) []otherIDXML {
+	var v []otherIDXML
+	for _, o := range ids {
+		x := otherIDXML{ID: o.ID, Issuer: o.Issuer}
+		if o.SchemeCode != "" || o.SchemeProprietary != "" {
+			x.SchemeName = &schemeNameXML{Code: o.SchemeCode, Proprietary: o.SchemeProprietary}
+		}
+		v = append(v, x)
+	}
+	return v
+}
+
+// partyID maps the layout back onto a PartyID
+func (v *partyIDXML) partyID() PartyID {
+	var id PartyID
+	if o := v.Organisation; o != nil {
+		id.Organisation = &OrganisationID{AnyBIC: o.AnyBIC, LEI: o.LEI, Other: otherIDs(o.Other)}
+		if o.BICOrBEI != "" {
+			id.Organisation.AnyBIC = o.BICOrBEI
+		}
+	}
+	if p := v.Private; p != nil {
+		id.Private = &PrivateID{Other: otherIDs(p.Other)}
+		if b := p.Birth; b != nil {
+			id.Private.BirthDate, id.Private.ProvinceOfBirth = b.BirthDate, b.ProvinceOfBirth
+			id.Private.CityOfBirth, id.Private.CountryOfBirth = b.CityOfBirth, b.CountryOfBirth
+		}
+	}
+	return id
+}
+
+// partyIDRef maps an optional layout back onto a PartyID
+func (v *partyIDXML) partyIDRef() *PartyID {
+	if v == nil {
+		return nil
+	}
+	id := v.partyID()
+	return &id
+}
+
+func otherIDs(v []otherIDXML) []OtherID {
+	var ids []OtherID
+	for _, x := range v {
+		o := OtherID{ID: x.ID, Issuer: x.Issuer}
+		if x.SchemeName != nil {
+			o.SchemeCode, o.SchemeProprietary = x.SchemeName.Code, x.SchemeName.Proprietary
+		}
+		ids = append(ids, o)
+	}
+	return ids
+}
@@ -0,0 +1,154 @@
+package sepa
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// MergeOptions tells MergeCreditTransfers how to build the merged document
+type MergeOptions struct {
+	// MsgID identifies the merged message, mandatory
+	MsgID string
+	// CreationDate is written as CreDtTm, the time of Now by default
+	CreationDate time.Time
+	// Now is the clock CreationDate defaults to, time.Now by default
+	Now func() time.Time
+	// CombineGroups combines the groups sharing execution date, debtor, debtor account and debtor agent into the first
+	// of them. Every group is kept on its own otherwise.
+	CombineGroups bool
+}
+
+// MergeCreditTransfers returns one document per debtor account (IBAN and debtor agent) with the transactions of all
+// documents paid from it, in their order, and the group header recomputed. The documents get MsgID followed by "/1",
+// "/2"... when there are several. The documents must share schema version and initiating party and their end to end
+// IDs must be unique, empty and NOTPROVIDED ones aside. A payment information ID already taken in a merged document gets "/2", "/3"... appended.
+// Message and payment information IDs made longer than 35 characters by their suffix are an error.
+// The documents themselves are left unchanged.
+func MergeCreditTransfers(o MergeOptions, docs ...*CreditTransfer) ([]*CreditTransfer, error) {
+	if o.MsgID == "" {
+		return nil, errors.New("missing message ID")
+	}
+	if len(docs) == 0 {
+		return nil, errors.New("no document to merge")
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	if o.CreationDate.IsZero() {
+		o.CreationDate = o.Now().Truncate(time.Second)
+	}
+	first := docs[0]
+	for _, doc := range docs[1:] {
+		if doc.Version() != first.Version() {
+			return nil, errors.New("can't merge " + doc.GroupHeaderMsgID + " in " + doc.Version() + " with " +
+				first.GroupHeaderMsgID + " in " + first.Version())
+		}
+		if !sameInitiator(doc, first) {
+			return nil, errors.New("can't merge " + doc.GroupHeaderMsgID + " initiated by " + doc.GroupHeaderEmitterName +
+				" with " + first.GroupHeaderMsgID + " initiated by " + first.GroupHeaderEmitterName)
+		}
+	}
+
+	var accounts []debtorAccount
+	groups := map[debtorAccount][]CreditPaymentInfo{}
+	endToEndIDs := map[string]string{}
+	for _, doc := range docs {
+		for _, p := range doc.PaymentInfos {
+			for _, t := range p.PaymentTransactions {
+				if t.TransactIDe2e == "" || t.TransactIDe2e == notProvided {
+					continue
+				}
+				if msgID, ok := endToEndIDs[t.TransactIDe2e]; ok {
+					return nil, errors.New("end to end ID " + t.TransactIDe2e + " both in " + msgID + " and " + doc.GroupHeaderMsgID)
+				}
+				endToEndIDs[t.TransactIDe2e] = doc.GroupHeaderMsgID
+			}
+			account := debtorAccount{iban: p.PaymentEmitterIBAN, agent: p.PaymentEmitterAgent.orNotProvided()}
+			if _, ok := groups[account]; !ok {
+				accounts = append(accounts, account)
+			}
+			groups[account] = append(groups[account], p)
+		}
+	}
+
+	merged := make([]*CreditTransfer, len(accounts))
+	for i, account := range accounts {
+		msgID, err := splitID("message ID", o.MsgID, i, len(accounts))
+		if err != nil {
+			return nil, err
+		}
+		if merged[i], err = mergeGroups(o, first, msgID, groups[account]); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+// debtorAccount is the account a credit transfer group is paid from, MergeCreditTransfers returns a document per account
+type debtorAccount struct {
+	iban  string
+	agent Agent
+}
+
+// sameInitiator tells whether two documents have the same initiating party : the same identification when one of
+// them has one, the same name otherwise
+func sameInitiator(a *CreditTransfer, b *CreditTransfer) bool {
+	if a.GroupHeaderEmitterID != nil || b.GroupHeaderEmitterID != nil {
+		return reflect.DeepEqual(a.GroupHeaderEmitterID, b.GroupHeaderEmitterID)
+	}
+	return a.GroupHeaderEmitterName == b.GroupHeaderEmitterName
+}
+
+// mergeGroups returns a document with the groups, in the schema version and with the initiating party of first
+func mergeGroups(o MergeOptions, first *CreditTransfer, msgID string, groups []CreditPaymentInfo) (*CreditTransfer, error) {
+	merged := &CreditTransfer{charset: first.charset, truncate: first.truncate}
+	if err := merged.init(first.Version(), msgID, DateTimeOf(o.CreationDate), first.GroupHeaderEmitterName); err != nil {
+		return nil, err
+	}
+	merged.GroupHeaderEmitterID = first.GroupHeaderEmitterID
+	for _, p := range groups {
+		if o.CombineGroups {
+			if pmtInf := merged.combinableGroup(p); pmtInf != nil {
+				pmtInf.PaymentTransactions = append(pmtInf.PaymentTransactions, p.PaymentTransactions...)
+				continue
+			}
+		}
+		group := p
+		group.PaymentEmitterPostalAddress = p.PaymentEmitterPostalAddress.clone()
+		group.PaymentTransactions = append([]CreditTransaction(nil), p.PaymentTransactions...)
+		for n := 2; merged.PaymentInfo(group.PaymentInfoID) != nil; n++ {
+			group.PaymentInfoID = p.PaymentInfoID + "/" + strconv.Itoa(n)
+			if err := checkID("payment info ID", group.PaymentInfoID); err != nil {
+				return nil, err
+			}
+		}
+		merged.PaymentInfos = append(merged.PaymentInfos, group)
+	}
+	if err := merged.Recalculate(); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// combinableGroup returns the group of the document sharing execution date, debtor, debtor account, debtor agent and
+// payment type with p, nil if there is none
+func (doc *CreditTransfer) combinableGroup(p CreditPaymentInfo) *CreditPaymentInfo {
+	for i := range doc.PaymentInfos {
+		if reflect.DeepEqual(doc.PaymentInfos[i].groupHeader(), p.groupHeader()) {
+			return &doc.PaymentInfos[i]
+		}
+	}
+	return nil
+}
+
+// groupHeader returns the group without its ID, totals and transactions
+func (p CreditPaymentInfo) groupHeader() CreditPaymentInfo {
+	p.PaymentInfoID = ""
+	p.PaymentInfoTransactNo = 0
+	p.PaymentInfoCtrlSum = Amount{}
+	p.PaymentTransactions = nil
+	p.PaymentEmitterPostalAddress = p.PaymentEmitterPostalAddress.clone()
+	return p
+}
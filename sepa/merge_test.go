@@ -0,0 +1,118 @@
+package sepa
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// mergeTestTransfer returns a credit transfer of the debtor account with a transaction per end to end ID
+func mergeTestTransfer(t *testing.T, msgID string, account Account, ids ...string) *CreditTransfer {
+	doc, err := NewCreditTransfer(CreditTransferConfig{MsgID: msgID, PaymentInfoID: "PMT-1", CreationDate: at("2017-05-01T22:45:03"),
+		ExecutionDate: day("2017-05-03"), Initiator: testEmitter, DebtorAccount: account})
+	if err != nil {
+		t.Fatal("Expected NewCreditTransfer return nil", "got", err)
+	}
+	for _, id := range ids {
+		if err := doc.AddTransaction(id, eur("10"), "EUR", "Creditor", "GB29NWBK60161331926819", "", "Invoice "+id); err != nil {
+			t.Fatal("Expected AddTransaction return nil", "got", err)
+		}
+	}
+	return doc
+}
+
+func TestMergeCreditTransfers(t *testing.T) {
+	payroll := mergeTestTransfer(t, "PAYROLL", testEmitterAccount, "P1", "P2")
+	suppliers := mergeTestTransfer(t, "SUPPLIERS", testEmitterAccount, "S1")
+	refunds := mergeTestTransfer(t, "REFUNDS", Account{IBAN: "AT611904300234573201"}, "R1", "R2", "R3")
+	now := func() time.Time { return at("2017-05-02T08:00:00") }
+
+	// one document per debtor account, every group on its own
+	merged, err := MergeCreditTransfers(MergeOptions{MsgID: "MERGED", Now: now}, payroll, suppliers, refunds)
+	if err != nil {
+		t.Fatal("Expected MergeCreditTransfers return nil", "got", err)
+	}
+	if len(merged) != 2 {
+		t.Fatal("Expected 2 documents", "got", len(merged))
+	}
+	if m := merged[0]; m.GroupHeaderMsgID != "MERGED/1" || m.GroupHeaderCreateDate.String() != "2017-05-02T08:00:00Z" ||
+		m.GroupHeaderTransactNo != 3 || m.GroupHeaderCtrlSum != eur("30") {
+		t.Error("Expected MERGED/1 with 3 transactions for 30.00", "got", m.GroupHeaderMsgID, m.GroupHeaderCreateDate,
+			m.GroupHeaderTransactNo, m.GroupHeaderCtrlSum)
+	}
+	for i, id := range []string{"PMT-1", "PMT-1/2"} {
+		if p := merged[0].PaymentInfos[i]; p.PaymentInfoID != id {
+			t.Error("Expected group", id, "got", p.PaymentInfoID)
+		}
+	}
+	if m := merged[1]; m.GroupHeaderMsgID != "MERGED/2" || len(m.PaymentInfos) != 1 || m.PaymentInfos[0].PaymentInfoID != "PMT-1" ||
+		m.PaymentInfos[0].PaymentInfoCtrlSum != eur("30") || m.PaymentInfos[0].PaymentEmitterIBAN != "AT611904300234573201" {
+		t.Error("Expected the refunds group alone in MERGED/2", "got", m.GroupHeaderMsgID, m.PaymentInfos)
+	}
+
+	// compatible groups combined
+	merged, err = MergeCreditTransfers(MergeOptions{MsgID: "MERGED", CombineGroups: true}, payroll, suppliers)
+	if err != nil {
+		t.Fatal("Expected MergeCreditTransfers return nil", "got", err)
+	}
+	if len(merged) != 1 || merged[0].GroupHeaderMsgID != "MERGED" || merged[0].GroupHeaderCreateDate.IsZero() {
+		t.Fatal("Expected a single document MERGED", "got", merged)
+	}
+	if p := merged[0].PaymentInfos; len(p) != 1 || p[0].PaymentInfoTransactNo != 3 || p[0].PaymentInfoCtrlSum != eur("30") {
+		t.Error("Expected payroll and suppliers combined in PMT-1", "got", p)
+	}
+
+	// sources left unchanged
+	if len(payroll.PaymentInfos[0].PaymentTransactions) != 2 || payroll.GroupHeaderCtrlSum != eur("20") {
+		t.Error("Expected payroll unchanged", "got", payroll.PaymentInfos[0].PaymentTransactions)
+	}
+
+	// refused
+	duplicate := mergeTestTransfer(t, "DUPLICATE", testEmitterAccount, "S1")
+	if _, err := MergeCreditTransfers(MergeOptions{MsgID: "MERGED"}, payroll, suppliers, duplicate); err == nil {
+		t.Error("Expected MergeCreditTransfers return an error for an end to end ID collision")
+	}
+	withoutID := mergeTestTransfer(t, "WITHOUT-ID", testEmitterAccount, "")
+	notProvidedID := mergeTestTransfer(t, "NOT-PROVIDED", testEmitterAccount, "", notProvided)
+	if merged, err := MergeCreditTransfers(MergeOptions{MsgID: "MERGED"}, withoutID, notProvidedID); err != nil || merged[0].GroupHeaderTransactNo != 3 {
+		t.Error("Expected MergeCreditTransfers merge transactions without end to end ID", "got", err)
+	}
+	other := mergeTestTransfer(t, "OTHER", testEmitterAccount, "O1")
+	other.GroupHeaderEmitterName = "Other Emitter"
+	if _, err := MergeCreditTransfers(MergeOptions{MsgID: "MERGED"}, payroll, other); err == nil {
+		t.Error("Expected MergeCreditTransfers return an error for another initiating party")
+	}
+	taxID := &PartyID{Organisation: &OrganisationID{Other: []OtherID{{ID: "DE123456789", SchemeCode: "TXID"}}}}
+	identified := mergeTestTransfer(t, "IDENTIFIED", testEmitterAccount, "I1")
+	identified.GroupHeaderEmitterID = taxID
+	if _, err := MergeCreditTransfers(MergeOptions{MsgID: "MERGED"}, payroll, identified); err == nil {
+		t.Error("Expected MergeCreditTransfers return an error for an initiating party with another identification")
+	}
+	renamed := mergeTestTransfer(t, "RENAMED", testEmitterAccount, "N1")
+	renamed.GroupHeaderEmitterName = "Emitter Name GmbH"
+	renamed.GroupHeaderEmitterID = taxID
+	merged, err = MergeCreditTransfers(MergeOptions{MsgID: "MERGED"}, identified, renamed)
+	if err != nil || len(merged) != 1 || merged[0].GroupHeaderEmitterID != taxID {
+		t.Error("Expected the documents of the same identified initiating party merged", "got", merged, err)
+	}
+	v09 := mergeTestTransfer(t, "V09", testEmitterAccount, "V1")
+	if err := v09.SetVersion(Pain001V09); err != nil {
+		t.Fatal("Expected SetVersion return nil", "got", err)
+	}
+	if _, err := MergeCreditTransfers(MergeOptions{MsgID: "MERGED"}, payroll, v09); err == nil {
+		t.Error("Expected MergeCreditTransfers return an error for another schema version")
+	}
+	long := mergeTestTransfer(t, "LONG", testEmitterAccount, "L1")
+	long.PaymentInfos[0].PaymentInfoID = strings.Repeat("P", 34)
+	longer := mergeTestTransfer(t, "LONGER", testEmitterAccount, "L2")
+	longer.PaymentInfos[0].PaymentInfoID = long.PaymentInfos[0].PaymentInfoID
+	if _, err := MergeCreditTransfers(MergeOptions{MsgID: "MERGED"}, long, longer); err == nil || !strings.Contains(err.Error(), "payment info ID") {
+		t.Error("Expected MergeCreditTransfers return an error for a payment info ID too long", "got", err)
+	}
+	if _, err := MergeCreditTransfers(MergeOptions{MsgID: strings.Repeat("M", 34)}, payroll, refunds); err == nil || !strings.Contains(err.Error(), "message ID") {
+		t.Error("Expected MergeCreditTransfers return an error for a message ID too long", "got", err)
+	}
+	if _, err := MergeCreditTransfers(MergeOptions{}, payroll); err == nil {
+		t.Error("Expected MergeCreditTransfers return an error for a missing message ID")
+	}
+}
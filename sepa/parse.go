@@ -0,0 +1,116 @@
+package sepa
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// rootElement reads up to the root element of a document, the decoder is then ready for DecodeElement
+func rootElement(d *xml.Decoder) (xml.StartElement, error) {
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return xml.StartElement{}, errors.New("empty xml document")
+		}
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
+}
+
+// documentAttrs returns the xsi:schemaLocation, xmlns and xmlns:xsi attributes of a root element.
+// encoding/xml resolves the prefixes, so the struct tags used for writing do not match them.
+func documentAttrs(start xml.StartElement) (xsiLoc string, ns string, xsi string) {
+	ns = start.Name.Space
+	for _, a := range start.Attr {
+		switch {
+		case a.Name.Local == "schemaLocation":
+			xsiLoc = a.Value
+		case a.Name.Space == "xmlns" && a.Name.Local == "xsi":
+			xsi = a.Value
+		}
+	}
+	return xsiLoc, ns, xsi
+}
+
+// ParseCreditTransfer reads a pain.001 document in any supported schema version
+func ParseCreditTransfer(r io.Reader) (*CreditTransfer, error) {
+	d := xml.NewDecoder(r)
+	start, err := rootElement(d)
+	if err != nil {
+		return nil, err
+	}
+	xsiLoc, ns, xsi := documentAttrs(start)
+	version := versionOf(ns)
+	if err := checkVersion(version, Pain001V03, Pain001V09); err != nil {
+		return nil, err
+	}
+
+	var doc *CreditTransfer
+	if version == Pain001V09 {
+		v09 := &creditTransferV09{}
+		if err := d.DecodeElement(v09, &start); err != nil {
+			return nil, err
+		}
+		doc = v09.creditTransfer()
+	} else {
+		doc = &CreditTransfer{}
+		if err := d.DecodeElement((*creditTransfer)(doc), &start); err != nil {
+			return nil, err
+		}
+	}
+	doc.XMLXsiLoc = xsiLoc
+	doc.XMLNs = ns
+	doc.XMLXsi = xsi
+	if doc.XMLXsiLoc == "" {
+		doc.XMLXsiLoc = schemaLocation(version)
+	}
+	return doc, nil
+}
+
+// ParseDirectDebit reads a pain.008 document in any supported schema version.
+// Transactions added afterwards start from the creditor information of the first payment information group.
+func ParseDirectDebit(r io.Reader) (*DirectDebit, error) {
+	d := xml.NewDecoder(r)
+	start, err := rootElement(d)
+	if err != nil {
+		return nil, err
+	}
+	xsiLoc, ns, xsi := documentAttrs(start)
+	version := versionOf(ns)
+	if err := checkVersion(version, Pain008DKV02, Pain008V02, Pain008V08); err != nil {
+		return nil, err
+	}
+
+	var doc *DirectDebit
+	if version == Pain008V08 {
+		v08 := &directDebitV08{}
+		if err := d.DecodeElement(v08, &start); err != nil {
+			return nil, err
+		}
+		doc = v08.directDebit()
+	} else {
+		doc = &DirectDebit{}
+		if err := d.DecodeElement((*directDebit)(doc), &start); err != nil {
+			return nil, err
+		}
+	}
+	doc.XMLXsiLoc = xsiLoc
+	doc.XMLNs = ns
+	doc.XMLXsi = xsi
+	if doc.XMLXsiLoc == "" {
+		doc.XMLXsiLoc = schemaLocation(version)
+	}
+	if len(doc.PaymentInfos) > 0 {
+		doc.paymentInfoID = doc.PaymentInfos[0].PaymentInfoID
+		doc.creditor = doc.PaymentInfos[0]
+		doc.creditor.PaymentInfoTransactNo = 0
+		doc.creditor.PaymentInfoCtrlSum = Amount{}
+		doc.creditor.PaymentTransactions = nil
+	}
+	return doc, nil
+}
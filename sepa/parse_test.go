@@ -0,0 +1,177 @@
+package sepa
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseCreditTransfer(t *testing.T) {
+	for _, version := range []string{Pain001V03, Pain001V09} {
+		var sepaDoc = &CreditTransfer{}
+		if err := sepaDoc.InitDoc("VIR201705", "PMT-1", "2017-05-01T22:45:03", "2017-05-03", "Franz Holzapfel GMBH", "FR1420041010050500013M02606", "BKAUATWW", "DE", "some street", "some city"); err != nil {
+			t.Fatal("Expected InitDoc return nil", "got", err)
+		}
+		if err := sepaDoc.SetVersion(version); err != nil {
+			t.Fatal("Expected SetVersion return nil", "got", err)
+		}
+		if err := sepaDoc.AddPaymentInfo("PMT-2", "2017-05-04", "Franz Holzapfel GMBH", "AT611904300234573201", "BKAUATWW", "DE", "some street", "some city"); err != nil {
+			t.Fatal("Expected AddPaymentInfo return nil", "got", err)
+		}
+		if err := sepaDoc.AddTransactionTo("PMT-1", "F1", eur("10.5"), "EUR", "DEF Electronics", "GB29NWBK60161331926819", "BKAUATWW", "Cables"); err != nil {
+			t.Error("Expected AddTransactionTo return nil", "got", err)
+		}
+		if err := sepaDoc.AddTransactionTo("PMT-2", "F2", eur("20.25"), "EUR", "D1F Electronics", "BE62510007547061", "", "Monitor"); err != nil {
+			t.Error("Expected AddTransactionTo return nil", "got", err)
+		}
+		str, err := sepaDoc.Serialize()
+		if err != nil {
+			t.Fatal("Expected xml in []byte, got ", err)
+		}
+		pretty, err := sepaDoc.PrettySerialize()
+		if err != nil {
+			t.Fatal("Expected indented xml in []byte, got ", err)
+		}
+
+		for _, in := range [][]byte{str, pretty} {
+			parsed, err := ParseCreditTransfer(bytes.NewReader(in))
+			if err != nil {
+				t.Fatal("Expected ParseCreditTransfer return nil", "got", err)
+			}
+			if parsed.Version() != version {
+				t.Error("Expected version", version, "got", parsed.Version())
+			}
+			if parsed.GroupHeaderTransactNo != 2 || parsed.GroupHeaderCtrlSum.Cmp(eur("30.75")) != 0 {
+				t.Error("Expected group header totals 2/30.75", "got", parsed.GroupHeaderTransactNo, parsed.GroupHeaderCtrlSum)
+			}
+			if p := parsed.PaymentInfo("PMT-2"); p == nil || p.PaymentTransactions[0].TransactCreditorIBAN != "BE62510007547061" {
+				t.Error("Expected PMT-2 with its transaction", "got", p)
+			}
+			reStr, err := parsed.Serialize()
+			if err != nil {
+				t.Fatal("Expected xml in []byte, got ", err)
+			}
+			if string(reStr) != string(str) {
+				t.Error("Expected", string(str), "got", string(reStr))
+			}
+		}
+	}
+
+	if _, err := ParseCreditTransfer(strings.NewReader(`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.04"/>`)); err == nil {
+		t.Error("Expected ParseCreditTransfer return an error for unsupported version")
+	}
+	if _, err := ParseCreditTransfer(strings.NewReader("")); err == nil {
+		t.Error("Expected ParseCreditTransfer return an error for empty document")
+	}
+}
+func TestParseDirectDebit(t *testing.T) {
+	for _, version := range []string{Pain008DKV02, Pain008V02, Pain008V08} {
+		var sepaDoc = &DirectDebit{}
+		if err := sepaDoc.InitDoc("MSGID", "PMT", "2017-06-07T14:39:33", "2017-06-11", "Emitter Name", "FR1420041010050500013M02606", "BKAUATWW", "DE98ZZZ09999999999", "DE", "some street", "some city"); err != nil {
+			t.Fatal("Expected InitDoc return nil", "got", err)
+		}
+		if err := sepaDoc.SetVersion(version); err != nil {
+			t.Fatal("Expected SetVersion return nil", "got", err)
+		}
+		if err := sepaDoc.AddTransaction("E2E-1", eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-1", "2017-01-01", SequenceFirst, ""); err != nil {
+			t.Error("Expected AddTransaction return nil", "got", err)
+		}
+		if err := sepaDoc.AddTransaction("E2E-2", eur("20.5"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-2", "2017-01-02", SequenceRecurring, ""); err != nil {
+			t.Error("Expected AddTransaction return nil", "got", err)
+		}
+		str, err := sepaDoc.Serialize()
+		if err != nil {
+			t.Fatal("Expected xml in []byte, got ", err)
+		}
+
+		parsed, err := ParseDirectDebit(bytes.NewReader(str))
+		if err != nil {
+			t.Fatal("Expected ParseDirectDebit return nil", "got", err)
+		}
+		if parsed.Version() != version {
+			t.Error("Expected version", version, "got", parsed.Version())
+		}
+		if parsed.GroupHeaderTransactNo != 2 || parsed.GroupHeaderCtrlSum.Cmp(eur("30.5")) != 0 || len(parsed.PaymentInfos) != 2 {
+			t.Error("Expected 2 payment infos with totals 2/30.5", "got", len(parsed.PaymentInfos), parsed.GroupHeaderTransactNo, parsed.GroupHeaderCtrlSum)
+		}
+		reStr, err := parsed.Serialize()
+		if err != nil {
+			t.Fatal("Expected xml in []byte, got ", err)
+		}
+		if string(reStr) != string(str) {
+			t.Error("Expected", string(str), "got", string(reStr))
+		}
+
+		// the parsed document keeps the creditor information for new transactions
+		if err := parsed.AddTransaction("E2E-3", eur("1"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-3", "2017-01-03", SequenceRecurring, ""); err != nil {
+			t.Error("Expected AddTransaction return nil", "got", err)
+		}
+		if len(parsed.PaymentInfos) != 2 || parsed.PaymentInfos[1].PaymentInfoTransactNo != 2 || parsed.GroupHeaderCtrlSum.Cmp(eur("31.5")) != 0 {
+			t.Error("Expected E2E-3 in the RCUR payment info", "got", parsed.PaymentInfos)
+		}
+	}
+
+	var doc = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.008.001.02" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <CstmrDrctDbtInitn>
+    <GrpHdr><MsgId>BILL-1</MsgId><CreDtTm>2017-06-07T14:39:33</CreDtTm><NbOfTxs>2</NbOfTxs><CtrlSum>30.00</CtrlSum><InitgPty><Nm>Billing</Nm></InitgPty></GrpHdr>
+    <PmtInf>
+      <PmtInfId>P1</PmtInfId><PmtMtd>DD</PmtMtd><NbOfTxs>1</NbOfTxs><CtrlSum>10.00</CtrlSum>
+      <PmtTpInf><SvcLvl><Cd>SEPA</Cd></SvcLvl><LclInstrm><Cd>CORE</Cd></LclInstrm><SeqTp>OOFF</SeqTp></PmtTpInf>
+      <ReqdColltnDt>2017-06-11</ReqdColltnDt>
+      <Cdtr><Nm>Creditor</Nm></Cdtr><CdtrAcct><Id><IBAN>FR1420041010050500013M02606</IBAN></Id></CdtrAcct><CdtrAgt><FinInstnId><BIC>BKAUATWW</BIC></FinInstnId></CdtrAgt>
+      <CdtrSchmeId><Id><PrvtId><Othr><Id>DE98ZZZ09999999999</Id><SchmeNm><Prtry>SEPA</Prtry></SchmeNm></Othr></PrvtId></Id></CdtrSchmeId>
+      <DrctDbtTxInf><PmtId><EndToEndId>E1</EndToEndId></PmtId><InstdAmt Ccy="EUR">10.00</InstdAmt><DrctDbtTx><MndtRltdInf><MndtId>M1</MndtId><DtOfSgntr>2016-01-01</DtOfSgntr></MndtRltdInf></DrctDbtTx><DbtrAgt><FinInstnId><BIC>BFAUAUWA</BIC></FinInstnId></DbtrAgt><Dbtr><Nm>D1</Nm></Dbtr><DbtrAcct><Id><IBAN>GB29NWBK60161331926819</IBAN></Id></DbtrAcct></DrctDbtTxInf>
+    </PmtInf>
+    <PmtInf>
+      <PmtInfId>P2</PmtInfId><PmtMtd>DD</PmtMtd><NbOfTxs>1</NbOfTxs><CtrlSum>20.00</CtrlSum>
+      <PmtTpInf><SvcLvl><Cd>SEPA</Cd></SvcLvl><LclInstrm><Cd>CORE</Cd></LclInstrm><SeqTp>RCUR</SeqTp></PmtTpInf>
+      <ReqdColltnDt>2017-06-12</ReqdColltnDt>
+      <Cdtr><Nm>Creditor</Nm></Cdtr><CdtrAcct><Id><IBAN>FR1420041010050500013M02606</IBAN></Id></CdtrAcct><CdtrAgt><FinInstnId><BIC>BKAUATWW</BIC></FinInstnId></CdtrAgt>
+      <DrctDbtTxInf><PmtId><EndToEndId>E2</EndToEndId></PmtId><InstdAmt Ccy="EUR">20.00</InstdAmt><DrctDbtTx><MndtRltdInf><MndtId>M2</MndtId><DtOfSgntr>2016-02-01</DtOfSgntr></MndtRltdInf><CdtrSchmeId><Id><PrvtId><Othr><Id>AT61ZZZ01234567890</Id></Othr></PrvtId></Id></CdtrSchmeId></DrctDbtTx><DbtrAgt><FinInstnId><BIC>BFAUAUWA</BIC></FinInstnId></DbtrAgt><Dbtr><Nm>D2</Nm></Dbtr><DbtrAcct><Id><IBAN>BE62510007547061</IBAN></Id></DbtrAcct></DrctDbtTxInf>
+    </PmtInf>
+  </CstmrDrctDbtInitn>
+</Document>`
+	parsed, err := ParseDirectDebit(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal("Expected ParseDirectDebit return nil", "got", err)
+	}
+	if parsed.Version() != Pain008V02 || parsed.GroupHeaderTransactNo != 2 || parsed.GroupHeaderCtrlSum.Cmp(eur("30")) != 0 {
+		t.Error("Expected pain.008.001.02 with totals 2/30", "got", parsed.Version(), parsed.GroupHeaderTransactNo, parsed.GroupHeaderCtrlSum)
+	}
+	expected := []struct {
+		mandantID     string
+		signatureDate string
+		sequenceType  string
+		schemeID      string
+	}{
+		{"M1", "2016-01-01", SequenceOneOff, "DE98ZZZ09999999999"},
+		{"M2", "2016-02-01", SequenceRecurring, "AT61ZZZ01234567890"},
+	}
+	for i, e := range expected {
+		p := &parsed.PaymentInfos[i]
+		tx := p.PaymentTransactions[0]
+		if tx.TransactMandantId != e.mandantID || tx.TransactMandantSignatureDate.String() != e.signatureDate || p.PaymentTypeSequence != e.sequenceType || p.CreditorSchemeID(tx) != e.schemeID {
+			t.Error("Expected transaction", e, "got", tx.TransactMandantId, tx.TransactMandantSignatureDate, p.PaymentTypeSequence, p.CreditorSchemeID(tx))
+		}
+	}
+
+	// the creditor scheme ID of a transaction is written back with its scheme name, only when it is set
+	for _, version := range []string{Pain008V02, Pain008V08} {
+		if err := parsed.SetVersion(version); err != nil {
+			t.Fatal("Expected SetVersion return nil", "got", err)
+		}
+		str, err := parsed.Serialize()
+		if err != nil {
+			t.Fatal("Expected Serialize return nil", "got", err)
+		}
+		if strings.Count(string(str), "<CdtrSchmeId>") != 3 ||
+			!strings.Contains(string(str), "<Othr><Id>AT61ZZZ01234567890</Id><SchmeNm><Prtry>SEPA</Prtry></SchmeNm></Othr>") {
+			t.Error("Expected the creditor scheme ID of M2 only with its scheme name in", version, "got", string(str))
+		}
+	}
+
+	if _, err := ParseDirectDebit(strings.NewReader(`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"/>`)); err == nil {
+		t.Error("Expected ParseDirectDebit return an error for a credit transfer")
+	}
+}
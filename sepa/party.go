@@ -0,0 +1,262 @@
+package sepa
+
+import (
+	"encoding/xml"
+	"errors"
+	"github.com/flofuenf/gosepa/lib"
+	"regexp"
+	"strings"
+)
+
+// NotProvided stands for the BIC of an agent that isn't known, the IBAN is enough to route SEPA payments
+const NotProvided = "NOTPROVIDED"
+
+var (
+	bicPattern      = regexp.MustCompile(`^[A-Z]{6}[A-Z2-9][A-NP-Z0-9]([A-Z0-9]{3})?$`)
+	countryPattern  = regexp.MustCompile(`^[A-Z]{2}$`)
+	currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+	leiPattern      = regexp.MustCompile(`^[A-Z0-9]{18}[0-9]{2}$`)
+)
+
+// Party is a debtor, creditor or initiating party
+type Party struct {
+	Name    string
+	Address PostalAddress
+	// ID is written as the Id element of the party, left out when empty
+	ID PartyID
+}
+
+// Validate returns an error if the party has no name, an invalid address or an invalid identification
+func (p Party) Validate() error {
+	if p.Name == "" {
+		return errors.New("missing party name")
+	}
+	if p.Address.Country != "" && !countryPattern.MatchString(p.Address.Country) {
+		return errors.New("invalid country code " + p.Address.Country)
+	}
+	return p.ID.Validate()
+}
+
+// PartyID identifies a party as an organisation or as a private person, set at most one of them
+type PartyID struct {
+	Organisation *OrganisationID
+	Private      *PrivateID
+}
+
+// OrganisationID identifies an organisation
+type OrganisationID struct {
+	// AnyBIC is written as BICOrBEI in pain.001.001.03 and pain.008.001.02
+	AnyBIC string
+	// LEI is the legal entity identifier, from pain.001.001.09 and pain.008.001.08 on
+	LEI   string
+	Other []OtherID
+}
+
+// PrivateID identifies a private person
+type PrivateID struct {
+	// BirthDate is optional, CityOfBirth and CountryOfBirth are mandatory with it
+	BirthDate       Date
+	ProvinceOfBirth string
+	CityOfBirth     string
+	CountryOfBirth  string
+	Other           []OtherID
+}
+
+// OtherID is an identification in a scheme given by code, "TXID" or "CUST" for instance, or by proprietary name
+type OtherID struct {
+	ID                string
+	SchemeCode        string
+	SchemeProprietary string
+	Issuer            string
+}
+
+// isEmpty tells whether no identification is set
+func (id PartyID) isEmpty() bool {
+	return id.Organisation == nil && id.Private == nil
+}
+
+// ref returns the identification, nil if it is empty
+func (id PartyID) ref() *PartyID {
+	if id.isEmpty() {
+		return nil
+	}
+	return &id
+}
+
+// Validate returns an error if both an organisation and a private identification are set or one of them is invalid
+func (id PartyID) Validate() error {
+	if id.Organisation != nil && id.Private != nil {
+		return errors.New("party identified both as organisation and private person")
+	}
+	if o := id.Organisation; o != nil {
+		if o.AnyBIC == "" && o.LEI == "" && len(o.Other) == 0 {
+			return errors.New("empty organisation identification")
+		}
+		if o.AnyBIC != "" && !bicPattern.MatchString(o.AnyBIC) {
+			return errors.New("invalid BIC " + o.AnyBIC)
+		}
+		if o.LEI != "" && !leiPattern.MatchString(o.LEI) {
+			return errors.New("invalid LEI " + o.LEI)
+		}
+		return validateOtherIDs(o.Other)
+	}
+	if p := id.Private; p != nil {
+		if p.BirthDate.IsZero() && len(p.Other) == 0 {
+			return errors.New("empty private identification")
+		}
+		if !p.BirthDate.IsZero() {
+			if err := checkDate("birth date", p.BirthDate); err != nil {
+				return err
+			}
+			if p.CityOfBirth == "" || !countryPattern.MatchString(p.CountryOfBirth) {
+				return errors.New("birth date needs city and country of birth")
+			}
+		}
+		return validateOtherIDs(p.Other)
+	}
+	return nil
+}
+
+// validateOtherIDs returns an error for the first identification without ID or with two scheme names
+func validateOtherIDs(ids []OtherID) error {
+	for _, o := range ids {
+		if o.ID == "" {
+			return errors.New("missing other identification")
+		}
+		if o.SchemeCode != "" && o.SchemeProprietary != "" {
+			return errors.New("identification " + o.ID + " has both a scheme code and a proprietary scheme")
+		}
+	}
+	return nil
+}
+
+// MarshalXML writes the identification in the pain.001.001.03 and pain.008.001.02 layout
+func (id PartyID) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if id.Organisation != nil && id.Organisation.LEI != "" {
+		return errors.New("LEI " + id.Organisation.LEI + " needs pain.001.001.09 or pain.008.001.08")
+	}
+	return e.EncodeElement(newPartyIDXML(id, false), start)
+}
+
+// UnmarshalXML reads an identification written in any supported layout
+func (id *PartyID) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v partyIDXML
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*id = v.partyID()
+	return nil
+}
+
+// postalAddress returns the address of the party, nil if it is empty
+func (p Party) postalAddress() *PostalAddress {
+	if p.Address.isEmpty() {
+		return nil
+	}
+	a := p.Address
+	a.AddressLines = append([]string(nil), a.AddressLines...)
+	return &a
+}
+
+// clone returns a copy of an optional address not sharing its address lines
+func (a *PostalAddress) clone() *PostalAddress {
+	if a == nil {
+		return nil
+	}
+	c := *a
+	c.AddressLines = append([]string(nil), a.AddressLines...)
+	return &c
+}
+
+// isEmpty tells whether no element of the address is set
+func (a PostalAddress) isEmpty() bool {
+	return a.StreetName == "" && a.BuildingNumber == "" && a.PostCode == "" && a.TownName == "" && a.Country == "" &&
+		len(a.AddressLines) == 0
+}
+
+// Account is a bank account, its currency is only needed when it isn't the currency of the payments
+type Account struct {
+	IBAN     string
+	Currency string
+}
+
+// normalized returns the account with the spaces removed from its IBAN
+func (a Account) normalized() Account {
+	a.IBAN = strings.Join(strings.Fields(a.IBAN), "")
+	return a
+}
+
+// Validate returns an error if the IBAN or the currency is invalid
+func (a Account) Validate() error {
+	if !lib.IsValid(a.normalized().IBAN) {
+		return errors.New("invalid IBAN " + a.IBAN)
+	}
+	if a.Currency != "" && !currencyPattern.MatchString(a.Currency) {
+		return errors.New("invalid currency " + a.Currency)
+	}
+	return nil
+}
+
+// Agent is the bank of an account, identified by its BIC or NotProvided.
+// The zero value is an agent left out of optional elements, it is written as NotProvided where one is mandatory.
+type Agent struct {
+	BIC string
+}
+
+// Validate returns an error if the BIC is neither empty, NotProvided nor a valid BIC
+func (a Agent) Validate() error {
+	if a.BIC == "" || a.BIC == NotProvided || bicPattern.MatchString(a.BIC) {
+		return nil
+	}
+	return errors.New("invalid BIC " + a.BIC)
+}
+
+// orNotProvided returns the agent, NotProvided if it is the zero value
+func (a Agent) orNotProvided() Agent {
+	if a.BIC == "" {
+		return Agent{BIC: NotProvided}
+	}
+	return a
+}
+
+// agentXML is the BranchAndFinancialInstitutionIdentification4 layout of an Agent
+type agentXML struct {
+	BIC   string   `xml:"FinInstnId>BIC,omitempty"`
+	Other *otherID `xml:"FinInstnId>Othr"`
+}
+
+// otherID is the Othr element identifying an agent without BIC
+type otherID struct {
+	ID string `xml:"Id"`
+}
+
+// MarshalXML writes the BIC of the agent or NOTPROVIDED as other identification, nothing for the zero value
+func (a Agent) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	switch a.BIC {
+	case "":
+		return nil
+	case NotProvided:
+		return e.EncodeElement(agentXML{Other: &otherID{ID: NotProvided}}, start)
+	}
+	return e.EncodeElement(agentXML{BIC: a.BIC}, start)
+}
+
+// UnmarshalXML reads an agent identified by BIC, BICFI or other identification
+func (a *Agent) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v struct {
+		agentXML
+		BICFI string `xml:"FinInstnId>BICFI"`
+	}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	switch {
+	case v.BIC != "":
+		a.BIC = v.BIC
+	case v.BICFI != "":
+		a.BIC = v.BICFI
+	case v.Other != nil:
+		a.BIC = v.Other.ID
+	}
+	return nil
+}
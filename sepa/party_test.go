@@ -0,0 +1,270 @@
+package sepa
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPartyValidate(t *testing.T) {
+	if err := (Party{Name: "Creditor", Address: PostalAddress{Country: "DE"}}).Validate(); err != nil {
+		t.Error("Expected Validate return nil", "got", err)
+	}
+	for _, p := range []Party{{}, {Name: "Creditor", Address: PostalAddress{Country: "Germany"}}} {
+		if err := p.Validate(); err == nil {
+			t.Error("Expected Validate return an error for", p)
+		}
+	}
+	if err := (Account{IBAN: "GB29 NWBK 6016 1331 9268 19", Currency: "GBP"}).Validate(); err != nil {
+		t.Error("Expected Validate return nil", "got", err)
+	}
+	for _, a := range []Account{{}, {IBAN: "GB29NWBK60161331926818"}, {IBAN: "GB29NWBK60161331926819", Currency: "pounds"}} {
+		if err := a.Validate(); err == nil {
+			t.Error("Expected Validate return an error for", a)
+		}
+	}
+	for _, a := range []Agent{{}, {BIC: NotProvided}, {BIC: "BKAUATWW"}, {BIC: "DEUTDEFF500"}} {
+		if err := a.Validate(); err != nil {
+			t.Error("Expected Validate return nil for", a, "got", err)
+		}
+	}
+	for _, a := range []Agent{{BIC: "bkauatww"}, {BIC: "BKAUAT"}, {BIC: "GB29NWBK60161331926819"}} {
+		if err := a.Validate(); err == nil {
+			t.Error("Expected Validate return an error for", a)
+		}
+	}
+}
+func TestSharedParties(t *testing.T) {
+	// master data shared by both message types
+	emitter := Party{Name: "Emitter Name", Address: PostalAddress{Country: "DE", AddressLines: []string{"some street", "some city"}}}
+	emitterAccount := Account{IBAN: "FR1420041010050500013M02606"}
+	customer := Party{Name: "Customer", Address: PostalAddress{Country: "GB", AddressLines: []string{"1 High Street", "London"}}}
+	customerAccount := Account{IBAN: "GB29NWBK60161331926819", Currency: "GBP"}
+
+	ct, err := NewCreditTransfer(CreditTransferConfig{MsgID: "CT", CreationDate: at("2017-06-07T14:39:33"), ExecutionDate: day("2017-06-11"),
+		Initiator: emitter, DebtorAccount: emitterAccount})
+	if err != nil {
+		t.Fatal("Expected NewCreditTransfer return nil", "got", err)
+	}
+	if err := ct.AddTransfer(Transfer{ID: "F1", Amount: eur("10"), Currency: "EUR", Creditor: customer, CreditorAccount: customerAccount,
+		Description: "Refund"}); err != nil {
+		t.Fatal("Expected AddTransfer return nil", "got", err)
+	}
+	if err := ct.AddTransfer(Transfer{ID: "F2", Amount: eur("10"), Currency: "EUR", Creditor: customer, CreditorAccount: customerAccount,
+		CreditorAgent: Agent{BIC: "NWBKGB2L1"}}); err == nil {
+		t.Error("Expected AddTransfer return an error for bad BIC")
+	}
+	if err := ct.AddTransfer(Transfer{ID: "F3", Amount: eur("10"), Currency: "EUR", CreditorAccount: customerAccount}); err == nil {
+		t.Error("Expected AddTransfer return an error for missing creditor name")
+	}
+	str, err := ct.Serialize()
+	if err != nil {
+		t.Fatal("Expected xml in []byte, got ", err)
+	}
+	for _, c := range []string{
+		"<DbtrAgt><FinInstnId><Othr><Id>NOTPROVIDED</Id></Othr></FinInstnId></DbtrAgt>",
+		"<Amt><InstdAmt Ccy=\"EUR\">10.00</InstdAmt></Amt><Cdtr><Nm>Customer</Nm><PstlAdr><Ctry>GB</Ctry><AdrLine>1 High Street</AdrLine><AdrLine>London</AdrLine></PstlAdr></Cdtr>",
+		"<CdtrAcct><Id><IBAN>GB29NWBK60161331926819</IBAN></Id><Ccy>GBP</Ccy></CdtrAcct>",
+	} {
+		if !strings.Contains(string(str), c) {
+			t.Error("Expected", c, "in", string(str))
+		}
+	}
+	if tx := ct.PaymentInfos[0].PaymentTransactions[0]; tx.Creditor().Name != customer.Name || tx.CreditorAccount() != customerAccount {
+		t.Error("Expected creditor", customer, customerAccount, "got", tx.Creditor(), tx.CreditorAccount())
+	}
+
+	dd, err := NewDirectDebit(DirectDebitConfig{MsgID: "DD", CreationDate: at("2017-06-07T14:39:33"), CollectionDate: day("2017-06-11"),
+		Initiator: emitter, CreditorAccount: emitterAccount, CreditorSchemeID: "DE98ZZZ09999999999"})
+	if err != nil {
+		t.Fatal("Expected NewDirectDebit return nil", "got", err)
+	}
+	if err := dd.AddCollection(Collection{ID: "E2E-1", Amount: eur("10"), Currency: "EUR", Debtor: customer, DebtorAccount: customerAccount,
+		MandateID: "MNDT-1", MandateSignatureDate: day("2017-01-01"), SequenceType: SequenceFirst}); err != nil {
+		t.Fatal("Expected AddCollection return nil", "got", err)
+	}
+	for _, version := range []string{Pain008DKV02, Pain008V08} {
+		if err := dd.SetVersion(version); err != nil {
+			t.Fatal("Expected SetVersion return nil", "got", err)
+		}
+		str, err := dd.Serialize()
+		if err != nil {
+			t.Fatal("Expected xml in []byte, got ", err)
+		}
+		for _, c := range []string{
+			"<CdtrAgt><FinInstnId><Othr><Id>NOTPROVIDED</Id></Othr></FinInstnId></CdtrAgt>",
+			"<DbtrAgt><FinInstnId><Othr><Id>NOTPROVIDED</Id></Othr></FinInstnId></DbtrAgt><Dbtr><Nm>Customer</Nm><PstlAdr><Ctry>GB</Ctry>",
+			"<DbtrAcct><Id><IBAN>GB29NWBK60161331926819</IBAN></Id><Ccy>GBP</Ccy></DbtrAcct>",
+		} {
+			if !strings.Contains(string(str), c) {
+				t.Error("Expected", c, "in", string(str))
+			}
+		}
+		parsed, err := ParseDirectDebit(bytes.NewReader(str))
+		if err != nil {
+			t.Fatal("Expected ParseDirectDebit return nil", "got", err)
+		}
+		tx := parsed.PaymentInfos[0].PaymentTransactions[0]
+		if tx.Debtor().Address.Country != "GB" || tx.DebtorAccount() != customerAccount || tx.TransactDebtorAgent.BIC != NotProvided {
+			t.Error("Expected debtor", customer, customerAccount, "got", tx.Debtor(), tx.DebtorAccount(), tx.TransactDebtorAgent)
+		}
+		if parsed.PaymentInfos[0].PaymentEmitterAgent.BIC != NotProvided {
+			t.Error("Expected creditor agent", NotProvided, "got", parsed.PaymentInfos[0].PaymentEmitterAgent)
+		}
+	}
+}
+func TestPartyID(t *testing.T) {
+	for _, id := range []PartyID{
+		{Organisation: &OrganisationID{AnyBIC: "BKAUATWW"}, Private: &PrivateID{Other: []OtherID{{ID: "1"}}}},
+		{Organisation: &OrganisationID{}},
+		{Organisation: &OrganisationID{LEI: "529900T8BM49AURSDO5"}},
+		{Organisation: &OrganisationID{Other: []OtherID{{ID: "DE123", SchemeCode: "TXID", SchemeProprietary: "VAT"}}}},
+		{Private: &PrivateID{BirthDate: day("1980-01-31"), CountryOfBirth: "DE"}},
+		{Private: &PrivateID{Other: []OtherID{{Issuer: "Registry"}}}},
+	} {
+		if err := id.Validate(); err == nil {
+			t.Error("Expected Validate return an error for", id)
+		}
+	}
+
+	doc, err := NewCreditTransfer(CreditTransferConfig{MsgID: "CT", CreationDate: at("2017-06-07T14:39:33"), ExecutionDate: day("2017-06-11"),
+		Initiator: Party{Name: "Emitter Name", ID: PartyID{Organisation: &OrganisationID{Other: []OtherID{{ID: "DE999999999", SchemeCode: "TXID"}}}}},
+		Debtor: Party{Name: "Emitter Subsidiary", ID: PartyID{Organisation: &OrganisationID{
+			AnyBIC: "BKAUATWW", LEI: "529900T8BM49AURSDO55",
+			Other: []OtherID{{ID: "DE123456789", SchemeCode: "TXID", Issuer: "BZSt"}}}}},
+		DebtorAccount: Account{IBAN: "FR1420041010050500013M02606"}})
+	if err != nil {
+		t.Fatal("Expected NewCreditTransfer return nil", "got", err)
+	}
+	if err := doc.AddTransfer(Transfer{ID: "F1", Amount: eur("10"), Currency: "EUR", CreditorAccount: Account{IBAN: "GB29NWBK60161331926819"},
+		Creditor: Party{Name: "Customer", ID: PartyID{Private: &PrivateID{BirthDate: day("1980-01-31"), CityOfBirth: "Berlin", CountryOfBirth: "DE",
+			Other: []OtherID{{ID: "C-42", SchemeProprietary: "Customer number"}}}}}}); err != nil {
+		t.Fatal("Expected AddTransfer return nil", "got", err)
+	}
+	if err := doc.AddTransfer(Transfer{ID: "F2", Amount: eur("10"), Currency: "EUR", Creditor: Party{Name: "Customer"},
+		CreditorAccount: Account{IBAN: "GB29NWBK60161331926819"}}); err != nil {
+		t.Fatal("Expected AddTransfer return nil", "got", err)
+	}
+	if _, err := doc.Serialize(); err == nil {
+		t.Error("Expected Serialize return an error for a LEI in pain.001.001.03")
+	}
+	doc.PaymentInfos[0].PaymentEmitterDebitorID.Organisation.LEI = ""
+	str, err := doc.Serialize()
+	if err != nil {
+		t.Fatal("Expected xml in []byte, got ", err)
+	}
+	for _, c := range []string{
+		"<InitgPty><Nm>Emitter Name</Nm><Id><OrgId><Othr><Id>DE999999999</Id><SchmeNm><Cd>TXID</Cd></SchmeNm></Othr></OrgId></Id></InitgPty>",
+		"<Dbtr><Nm>Emitter Subsidiary</Nm><Id><OrgId><BICOrBEI>BKAUATWW</BICOrBEI><Othr><Id>DE123456789</Id><SchmeNm><Cd>TXID</Cd></SchmeNm><Issr>BZSt</Issr></Othr></OrgId></Id></Dbtr>",
+		"<Cdtr><Nm>Customer</Nm><Id><PrvtId><DtAndPlcOfBirth><BirthDt>1980-01-31</BirthDt><CityOfBirth>Berlin</CityOfBirth><CtryOfBirth>DE</CtryOfBirth></DtAndPlcOfBirth><Othr><Id>C-42</Id><SchmeNm><Prtry>Customer number</Prtry></SchmeNm></Othr></PrvtId></Id></Cdtr>",
+		"<Cdtr><Nm>Customer</Nm></Cdtr>",
+	} {
+		if !strings.Contains(string(str), c) {
+			t.Error("Expected", c, "in", string(str))
+		}
+	}
+
+	if err := doc.Validate(); err != nil {
+		t.Error("Expected Validate return nil", "got", err)
+	}
+
+	doc.PaymentInfos[0].PaymentEmitterDebitorID.Organisation.LEI = "529900T8BM49AURSDO55"
+	if err := doc.SetVersion(Pain001V09); err != nil {
+		t.Fatal("Expected SetVersion return nil", "got", err)
+	}
+	str, err = doc.Serialize()
+	if err != nil {
+		t.Fatal("Expected xml in []byte, got ", err)
+	}
+	if c := "<Id><OrgId><AnyBIC>BKAUATWW</AnyBIC><LEI>529900T8BM49AURSDO55</LEI><Othr>"; !strings.Contains(string(str), c) {
+		t.Error("Expected", c, "in", string(str))
+	}
+	parsed, err := ParseCreditTransfer(bytes.NewReader(str))
+	if err != nil {
+		t.Fatal("Expected ParseCreditTransfer return nil", "got", err)
+	}
+	if debtor := parsed.PaymentInfos[0].Debtor(); debtor.ID.Organisation == nil || debtor.ID.Organisation.LEI != "529900T8BM49AURSDO55" ||
+		debtor.ID.Organisation.Other[0].Issuer != "BZSt" {
+		t.Error("Expected debtor identification", doc.PaymentInfos[0].Debtor().ID, "got", debtor.ID)
+	}
+	if id := parsed.GroupHeaderEmitterID; id == nil || id.Organisation == nil || id.Organisation.Other[0].ID != "DE999999999" {
+		t.Error("Expected initiator identification", doc.GroupHeaderEmitterID, "got", id)
+	}
+	if creditor := parsed.PaymentInfos[0].PaymentTransactions[0].Creditor(); creditor.ID.Private == nil || creditor.ID.Private.CityOfBirth != "Berlin" {
+		t.Error("Expected creditor identification", "got", creditor.ID)
+	}
+}
+
+func TestDirectDebitPartyID(t *testing.T) {
+	taxID := PartyID{Organisation: &OrganisationID{Other: []OtherID{{ID: "DE999999999", SchemeCode: "TXID"}}}}
+	c := DirectDebitConfig{MsgID: "DD", CreationDate: at("2017-06-07T14:39:33"), CollectionDate: day("2017-06-11"),
+		Initiator: Party{Name: "Emitter Name", ID: taxID}, CreditorAccount: testEmitterAccount, CreditorSchemeID: "DE98ZZZ09999999999"}
+	doc, err := NewDirectDebit(c)
+	if err != nil {
+		t.Fatal("Expected NewDirectDebit return nil", "got", err)
+	}
+	if err := doc.AddTransaction("E2E-1", eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-1", "2017-01-01", SequenceFirst, ""); err != nil {
+		t.Fatal("Expected AddTransaction return nil", "got", err)
+	}
+	str, err := doc.Serialize()
+	if err != nil {
+		t.Fatal("Expected xml in []byte, got ", err)
+	}
+	for _, c := range []string{
+		"<InitgPty><Nm>Emitter Name</Nm><Id><OrgId><Othr><Id>DE999999999</Id><SchmeNm><Cd>TXID</Cd></SchmeNm></Othr></OrgId></Id></InitgPty>",
+		"<Cdtr><Nm>Emitter Name</Nm></Cdtr>",
+	} {
+		if !strings.Contains(string(str), c) {
+			t.Error("Expected", c, "in", string(str))
+		}
+	}
+	if err := doc.Validate(); err != nil {
+		t.Error("Expected Validate return nil", "got", err)
+	}
+
+	c.Creditor = Party{Name: "Emitter Subsidiary", ID: taxID}
+	if _, err := NewDirectDebit(c); err == nil {
+		t.Error("Expected NewDirectDebit return an error for a creditor identification in pain.008.003.02")
+	}
+	c.Version = Pain008V02
+	if doc, err = NewDirectDebit(c); err != nil {
+		t.Fatal("Expected NewDirectDebit return nil", "got", err)
+	}
+	if err := doc.AddTransaction("E2E-1", eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-1", "2017-01-01", SequenceFirst, ""); err != nil {
+		t.Fatal("Expected AddTransaction return nil", "got", err)
+	}
+	if err := doc.SetVersion(Pain008DKV02); err == nil {
+		t.Error("Expected SetVersion return an error for a creditor identification in pain.008.003.02")
+	}
+	str, err = doc.Serialize()
+	if err != nil {
+		t.Fatal("Expected xml in []byte, got ", err)
+	}
+	if c := "<Cdtr><Nm>Emitter Subsidiary</Nm><Id><OrgId><Othr><Id>DE999999999</Id><SchmeNm><Cd>TXID</Cd></SchmeNm></Othr></OrgId></Id></Cdtr>"; !strings.Contains(string(str), c) {
+		t.Error("Expected", c, "in", string(str))
+	}
+	if err := doc.Validate(); err != nil {
+		t.Error("Expected Validate return nil", "got", err)
+	}
+
+	if err := doc.SetVersion(Pain008V08); err != nil {
+		t.Fatal("Expected SetVersion return nil", "got", err)
+	}
+	if err := doc.Validate(); err != nil {
+		t.Error("Expected Validate return nil", "got", err)
+	}
+	str, err = doc.Serialize()
+	if err != nil {
+		t.Fatal("Expected xml in []byte, got ", err)
+	}
+	parsed, err := ParseDirectDebit(bytes.NewReader(str))
+	if err != nil {
+		t.Fatal("Expected ParseDirectDebit return nil", "got", err)
+	}
+	if id := parsed.GroupHeaderEmitterID; id == nil || id.Organisation == nil || id.Organisation.Other[0].ID != "DE999999999" {
+		t.Error("Expected initiator identification", doc.GroupHeaderEmitterID, "got", id)
+	}
+	if creditor := parsed.PaymentInfos[0].Creditor(); creditor.ID.Organisation == nil || creditor.ID.Organisation.Other[0].ID != "DE999999999" {
+		t.Error("Expected creditor identification", "got", creditor.ID)
+	}
+}
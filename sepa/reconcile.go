@@ -0,0 +1,372 @@
+package sepa
+
+import "time"
+
+// IssuedPayment is a transaction of a generated document as it is expected on the account
+type IssuedPayment struct {
+	MsgID         string
+	PaymentInfoID string
+	EndToEndID    string
+	MandateID     string
+	Amount        TAmount
+	CreditDebit   string // Debit for a credit transfer leaving the account, Credit for a direct debit
+	Date          Date   // requested execution or collection date
+}
+
+// IssuedPayments returns the transactions of the document as payments expected as debits on the account
+func (doc *CreditTransfer) IssuedPayments() []IssuedPayment {
+	var issued []IssuedPayment
+	for _, p := range doc.PaymentInfos {
+		for _, t := range p.PaymentTransactions {
+			issued = append(issued, IssuedPayment{
+				MsgID:         doc.GroupHeaderMsgID,
+				PaymentInfoID: p.PaymentInfoID,
+				EndToEndID:    t.TransactIDe2e,
+				Amount:        t.TransactAmount,
+				CreditDebit:   Debit,
+				Date:          p.PaymentExecDate,
+			})
+		}
+	}
+	return issued
+}
+
+// IssuedPayments returns the transactions of the document as payments expected as credits on the account
+func (doc *DirectDebit) IssuedPayments() []IssuedPayment {
+	var issued []IssuedPayment
+	for _, p := range doc.PaymentInfos {
+		for _, t := range p.PaymentTransactions {
+			issued = append(issued, IssuedPayment{
+				MsgID:         doc.GroupHeaderMsgID,
+				PaymentInfoID: p.PaymentInfoID,
+				EndToEndID:    t.TransactIDe2e,
+				MandateID:     t.TransactMandantId,
+				Amount:        t.TransactAmount,
+				CreditDebit:   Credit,
+				Date:          p.PaymentExecDate,
+			})
+		}
+	}
+	return issued
+}
+
+// Transactions returns the booked transactions of all statements of the document
+func (doc *BankStatement) Transactions() []BookedTransaction {
+	var transactions []BookedTransaction
+	for i := range doc.Statements {
+		transactions = append(transactions, doc.Statements[i].Transactions()...)
+	}
+	return transactions
+}
+
+// Transactions returns the booked transactions of all notifications of the document
+func (doc *BankNotification) Transactions() []BookedTransaction {
+	var transactions []BookedTransaction
+	for i := range doc.Notifications {
+		transactions = append(transactions, doc.Notifications[i].Transactions()...)
+	}
+	return transactions
+}
+
+// Reconciliation is the result of matching issued payments against booked transactions
+type Reconciliation struct {
+	Matched         []ReconciledPayment // booked for the issued amount
+	PartiallyBooked []ReconciledPayment // booked for less than the issued amount so far
+	Returned        []ReconciledPayment // returned or reversed by the bank
+	Unmatched       []IssuedPayment     // nothing booked
+	Unexpected      []BookedTransaction // booked without any issued payment
+	Ambiguous       []AmbiguousBooking  // left to a human
+	Batches         []ReconciledBatch   // payment information groups booked at once for their total
+}
+
+// ReconciledPayment is an issued payment with the transactions booked for it
+type ReconciledPayment struct {
+	Issued IssuedPayment
+	Booked []BookedTransaction
+}
+
+// ReconciledBatch is a payment information group booked as a single entry without transaction details,
+// as banks do for groups sent with BtchBookg=true
+type ReconciledBatch struct {
+	MsgID         string
+	PaymentInfoID string
+	Issued        []IssuedPayment
+	Booked        BookedTransaction
+}
+
+// AmbiguousBooking is a booking that can't be matched to a single issued payment, or bookings exceeding their payment
+type AmbiguousBooking struct {
+	Reason     string
+	Booked     []BookedTransaction
+	Candidates []IssuedPayment
+}
+
+// notProvided is the end to end ID of transactions sent without one
+const notProvided = "NOTPROVIDED"
+
+// ReconcileOptions tunes the matching of ReconcileWith
+type ReconcileOptions struct {
+	// BusinessDays is how many business days, Monday to Friday, the booking or value date of a transaction without
+	// end to end ID may be away from the execution or collection date. Zero requires the same day.
+	BusinessDays int
+}
+
+// DefaultBusinessDays is the date window of Reconcile, banks book most payments on the day or the next business day
+const DefaultBusinessDays = 1
+
+// Reconcile matches booked transactions against issued payments within DefaultBusinessDays, see ReconcileWith
+func Reconcile(issued []IssuedPayment, booked []BookedTransaction) *Reconciliation {
+	return ReconcileWith(ReconcileOptions{BusinessDays: DefaultBusinessDays}, issued, booked)
+}
+
+// ReconcileWith matches booked transactions against issued payments, by end to end ID first,
+// then by mandate ID, amount and direction, then by amount, direction and date within o.BusinessDays.
+// An entry without transaction details matching no single payment is matched against the payment information groups :
+// the group named by its batch, or the group of its total, direction and date, with the number of transactions of
+// its batch when given. The payments of a group matched this way are only listed in Batches.
+// Only booked entries are considered, pending and informative ones are skipped.
+func ReconcileWith(o ReconcileOptions, issued []IssuedPayment, booked []BookedTransaction) *Reconciliation {
+	res := &Reconciliation{}
+	matches := make([][]BookedTransaction, len(issued))
+	batched := make([]bool, len(issued))
+	for _, b := range booked {
+		if b.Entry != nil && b.Entry.Status != "" && b.Entry.Status != "BOOK" {
+			continue
+		}
+		candidates := matchCandidates(o, issued, b)
+		if len(candidates) == 0 {
+			groups := batchCandidates(o, issued, b)
+			switch len(groups) {
+			case 0:
+				res.Unexpected = append(res.Unexpected, b)
+			case 1:
+				batch := ReconciledBatch{MsgID: issued[groups[0][0]].MsgID, PaymentInfoID: issued[groups[0][0]].PaymentInfoID, Booked: b}
+				for _, i := range groups[0] {
+					batch.Issued = append(batch.Issued, issued[i])
+					batched[i] = true
+				}
+				res.Batches = append(res.Batches, batch)
+			default:
+				a := AmbiguousBooking{Reason: "several payment information groups match", Booked: []BookedTransaction{b}}
+				for _, group := range groups {
+					for _, i := range group {
+						a.Candidates = append(a.Candidates, issued[i])
+					}
+				}
+				res.Ambiguous = append(res.Ambiguous, a)
+			}
+			continue
+		}
+		switch len(candidates) {
+		case 1:
+			matches[candidates[0]] = append(matches[candidates[0]], b)
+		default:
+			a := AmbiguousBooking{Reason: "several issued payments match", Booked: []BookedTransaction{b}}
+			for _, i := range candidates {
+				a.Candidates = append(a.Candidates, issued[i])
+			}
+			res.Ambiguous = append(res.Ambiguous, a)
+		}
+	}
+
+	for i, p := range issued {
+		if batched[i] {
+			continue
+		}
+		b := matches[i]
+		if len(b) == 0 {
+			res.Unmatched = append(res.Unmatched, p)
+			continue
+		}
+		item := ReconciledPayment{Issued: p, Booked: b}
+		if returned(b) {
+			res.Returned = append(res.Returned, item)
+			continue
+		}
+		sum, err := bookedSum(b, p.CreditDebit)
+		switch {
+		case err != nil:
+			res.Ambiguous = append(res.Ambiguous, AmbiguousBooking{Reason: "amount can't be compared", Booked: b, Candidates: []IssuedPayment{p}})
+		case sum.Cmp(p.Amount.Amount) == 0:
+			res.Matched = append(res.Matched, item)
+		case sum.Cmp(p.Amount.Amount) < 0:
+			res.PartiallyBooked = append(res.PartiallyBooked, item)
+		default:
+			res.Ambiguous = append(res.Ambiguous, AmbiguousBooking{Reason: "booked amount exceeds issued amount", Booked: b, Candidates: []IssuedPayment{p}})
+		}
+	}
+	return res
+}
+
+// matchCandidates returns the indexes of the issued payments a booked transaction may belong to
+func matchCandidates(o ReconcileOptions, issued []IssuedPayment, b BookedTransaction) []int {
+	if b.EndToEndID != "" && b.EndToEndID != notProvided {
+		var byID []int
+		for i, p := range issued {
+			if p.EndToEndID == b.EndToEndID {
+				byID = append(byID, i)
+			}
+		}
+		if len(byID) > 1 {
+			byID = narrow(byID, func(p IssuedPayment) bool {
+				return (b.PaymentInfoID == "" || b.PaymentInfoID == p.PaymentInfoID) && (b.MsgID == "" || b.MsgID == p.MsgID)
+			}, issued)
+		}
+		if len(byID) > 1 {
+			byID = narrow(byID, func(p IssuedPayment) bool { return sameAmount(p.Amount, b.Amount) }, issued)
+		}
+		return byID
+	}
+
+	var candidates []int
+	for i, p := range issued {
+		if !sameAmount(p.Amount, b.Amount) {
+			continue
+		}
+		if b.MandateID != "" {
+			if p.MandateID == b.MandateID && p.CreditDebit == b.CreditDebit {
+				candidates = append(candidates, i)
+			}
+			continue
+		}
+		if p.CreditDebit == b.CreditDebit && b.Entry != nil && o.nearDate(p.Date, b.Entry) {
+			candidates = append(candidates, i)
+		}
+	}
+	return candidates
+}
+
+// batchCandidates returns the payment information groups, as the indexes of their issued payments, an entry without
+// transaction details may book at once
+func batchCandidates(o ReconcileOptions, issued []IssuedPayment, b BookedTransaction) [][]int {
+	if b.Entry == nil || len(b.Entry.Details) > 0 {
+		return nil
+	}
+	var batch *EntryBatch
+	if len(b.Entry.Batches) == 1 {
+		batch = &b.Entry.Batches[0]
+	}
+	var keys []string
+	groups := map[string][]int{}
+	for i, p := range issued {
+		key := p.MsgID + "/" + p.PaymentInfoID
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+	var candidates [][]int
+	for _, key := range keys {
+		group := groups[key]
+		first := issued[group[0]]
+		if first.CreditDebit != b.CreditDebit || !sameGroupTotal(issued, group, b.Amount) {
+			continue
+		}
+		if batch != nil && batch.PaymentInfoID != "" {
+			if batch.PaymentInfoID != first.PaymentInfoID || (batch.MsgID != "" && batch.MsgID != first.MsgID) {
+				continue
+			}
+		} else if !o.nearDate(first.Date, b.Entry) {
+			continue
+		}
+		if batch != nil && batch.TransactNo > 0 && batch.TransactNo != len(group) {
+			continue
+		}
+		candidates = append(candidates, group)
+	}
+	return candidates
+}
+
+// sameGroupTotal tells whether the issued payments of a group add up to the amount
+func sameGroupTotal(issued []IssuedPayment, group []int, amount TAmount) bool {
+	var total Amount
+	for _, i := range group {
+		if issued[i].Amount.Currency != amount.Currency {
+			return false
+		}
+		var err error
+		if total, err = total.Add(issued[i].Amount.Amount); err != nil {
+			return false
+		}
+	}
+	return total.Cmp(amount.Amount) == 0
+}
+
+// narrow keeps the candidates accepted by keep, unless it would keep none of them
+func narrow(candidates []int, keep func(IssuedPayment) bool, issued []IssuedPayment) []int {
+	var kept []int
+	for _, i := range candidates {
+		if keep(issued[i]) {
+			kept = append(kept, i)
+		}
+	}
+	if len(kept) == 0 {
+		return candidates
+	}
+	return kept
+}
+
+// returned tells whether one of the bookings returns or reverses the payment
+func returned(booked []BookedTransaction) bool {
+	for _, b := range booked {
+		if b.Returned() || (b.Entry != nil && b.Entry.Reversal) {
+			return true
+		}
+	}
+	return false
+}
+
+// bookedSum sums the bookings in the direction of the payment, minus the ones in the other direction
+func bookedSum(booked []BookedTransaction, creditDebit string) (Amount, error) {
+	var sum Amount
+	var err error
+	for _, b := range booked {
+		if b.CreditDebit == creditDebit {
+			sum, err = sum.Add(b.Amount.Amount)
+		} else {
+			sum, err = sum.Sub(b.Amount.Amount)
+		}
+		if err != nil {
+			return Amount{}, err
+		}
+	}
+	return sum, nil
+}
+
+// sameAmount compares amounts in the same currency
+func sameAmount(a TAmount, b TAmount) bool {
+	return a.Currency == b.Currency && a.Amount.Cmp(b.Amount) == 0
+}
+
+// nearDate tells whether the booking or value date of an entry is within the business days of date
+func (o ReconcileOptions) nearDate(date Date, e *Entry) bool {
+	return withinBusinessDays(date.String(), e.BookingDate, o.BusinessDays) || withinBusinessDays(date.String(), e.ValueDate, o.BusinessDays)
+}
+
+// withinBusinessDays tells whether the days of two ISO dates or date times are at most n business days apart
+func withinBusinessDays(a string, b string, n int) bool {
+	if len(a) < 10 || len(b) < 10 {
+		return false
+	}
+	from, err := time.Parse("2006-01-02", a[:10])
+	if err != nil {
+		return false
+	}
+	to, err := time.Parse("2006-01-02", b[:10])
+	if err != nil {
+		return false
+	}
+	if to.Before(from) {
+		from, to = to, from
+	}
+	for days := 0; from.Before(to); {
+		from = from.AddDate(0, 0, 1)
+		if from.Weekday() != time.Saturday && from.Weekday() != time.Sunday {
+			days++
+		}
+		if days > n {
+			return false
+		}
+	}
+	return true
+}
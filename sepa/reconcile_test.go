@@ -0,0 +1,191 @@
+package sepa
+
+import (
+	"testing"
+)
+
+func TestReconcile(t *testing.T) {
+	var ddDoc = &DirectDebit{}
+	if err := ddDoc.InitDoc("MSGID", "PMT", "2017-06-07T14:39:33", "2017-06-11", "Emitter Name", "FR1420041010050500013M02606", "BKAUATWW", "DE98ZZZ09999999999", "DE", "some street", "some city"); err != nil {
+		t.Fatal("Expected InitDoc return nil", "got", err)
+	}
+	TTest := []struct {
+		id     string
+		amount Amount
+	}{
+		{"E2E-1", eur("10")}, {"E2E-2", eur("20.5")}, {"E2E-3", eur("5")}, {"E2E-4", eur("7")}, {"E2E-5", eur("8")},
+	}
+	for _, transact := range TTest {
+		if err := ddDoc.AddTransaction(transact.id, transact.amount, "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-"+transact.id, "2017-01-01", SequenceFirst, ""); err != nil {
+			t.Error("Expected AddTransaction return nil", "got", err)
+		}
+	}
+	var ctDoc = &CreditTransfer{}
+	if err := ctDoc.InitDoc("VIR201705", "PMT-1", "2017-05-01T22:45:03", "2017-06-11", "Emitter Name", "FR1420041010050500013M02606", "BKAUATWW", "DE", "some street", "some city"); err != nil {
+		t.Fatal("Expected InitDoc return nil", "got", err)
+	}
+	for _, id := range []string{"F1", "F2"} {
+		if err := ctDoc.AddTransaction(id, eur("70"), "EUR", "DEF Electronics", "GB29NWBK60161331926819", "BKAUATWW", "Cables"); err != nil {
+			t.Error("Expected AddTransaction return nil", "got", err)
+		}
+	}
+	if err := ctDoc.AddTransaction("F3", eur("99"), "EUR", "DEF Electronics", "GB29NWBK60161331926819", "BKAUATWW", "Cables"); err != nil {
+		t.Error("Expected AddTransaction return nil", "got", err)
+	}
+
+	entry := &Entry{Status: "BOOK", BookingDate: "2017-06-11", ValueDate: "2017-06-11"}
+	booked := func(d EntryDetails) BookedTransaction {
+		return BookedTransaction{EntryDetails: d, Entry: entry}
+	}
+	inEUR := func(amount string) TAmount {
+		return TAmount{Amount: eur(amount), Currency: "EUR"}
+	}
+	bookings := []BookedTransaction{
+		booked(EntryDetails{EndToEndID: "E2E-1", Amount: inEUR("10"), CreditDebit: Credit}),
+		booked(EntryDetails{EndToEndID: "E2E-2", Amount: inEUR("10"), CreditDebit: Credit}),
+		booked(EntryDetails{EndToEndID: "E2E-3", Amount: inEUR("5"), CreditDebit: Credit}),
+		booked(EntryDetails{EndToEndID: "E2E-3", Amount: inEUR("5"), CreditDebit: Debit, ReturnReason: "MD06"}),
+		booked(EntryDetails{EndToEndID: notProvided, MandateID: "MNDT-E2E-4", Amount: inEUR("7"), CreditDebit: Credit}),
+		booked(EntryDetails{Amount: inEUR("70"), CreditDebit: Debit}),
+		booked(EntryDetails{Amount: inEUR("99"), CreditDebit: Debit}),
+		booked(EntryDetails{EndToEndID: "UNKNOWN", Amount: inEUR("1"), CreditDebit: Credit}),
+		{EntryDetails: EntryDetails{EndToEndID: "E2E-5", Amount: inEUR("8"), CreditDebit: Credit}, Entry: &Entry{Status: "PDNG"}},
+	}
+
+	issued := append(ddDoc.IssuedPayments(), ctDoc.IssuedPayments()...)
+	res := Reconcile(issued, bookings)
+
+	ids := func(items []ReconciledPayment) []string {
+		var l []string
+		for _, item := range items {
+			l = append(l, item.Issued.EndToEndID)
+		}
+		return l
+	}
+	expected := []struct {
+		name string
+		got  []string
+		ids  []string
+	}{
+		{"matched", ids(res.Matched), []string{"E2E-1", "E2E-4", "F3"}},
+		{"partially booked", ids(res.PartiallyBooked), []string{"E2E-2"}},
+		{"returned", ids(res.Returned), []string{"E2E-3"}},
+	}
+	for _, e := range expected {
+		if len(e.got) != len(e.ids) {
+			t.Error("Expected", e.name, e.ids, "got", e.got)
+			continue
+		}
+		for i := range e.ids {
+			if e.got[i] != e.ids[i] {
+				t.Error("Expected", e.name, e.ids, "got", e.got)
+			}
+		}
+	}
+	if len(res.Unmatched) != 3 || res.Unmatched[0].EndToEndID != "E2E-5" {
+		t.Error("Expected E2E-5, F1 and F2 unmatched", "got", res.Unmatched)
+	}
+	if len(res.Unexpected) != 1 || res.Unexpected[0].EndToEndID != "UNKNOWN" {
+		t.Error("Expected UNKNOWN unexpected", "got", res.Unexpected)
+	}
+	if len(res.Ambiguous) != 1 || len(res.Ambiguous[0].Candidates) != 2 {
+		t.Error("Expected the 70 EUR booking ambiguous between F1 and F2", "got", res.Ambiguous)
+	}
+}
+func TestReconcileBatch(t *testing.T) {
+	var ddDoc = &DirectDebit{}
+	if err := ddDoc.InitDoc("MSGID", "PMT", "2017-06-07T14:39:33", "2017-06-11", "Emitter Name", "FR1420041010050500013M02606", "BKAUATWW", "DE98ZZZ09999999999", "DE", "some street", "some city"); err != nil {
+		t.Fatal("Expected InitDoc return nil", "got", err)
+	}
+	TTest := []struct {
+		id       string
+		amount   Amount
+		sequence string
+	}{
+		{"E2E-1", eur("10"), SequenceFirst}, {"E2E-2", eur("20"), SequenceFirst},
+		{"E2E-3", eur("5"), SequenceRecurring}, {"E2E-4", eur("7"), SequenceRecurring},
+	}
+	for _, transact := range TTest {
+		if err := ddDoc.AddTransaction(transact.id, transact.amount, "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-"+transact.id, "2017-01-01", transact.sequence, ""); err != nil {
+			t.Error("Expected AddTransaction return nil", "got", err)
+		}
+	}
+	issued := ddDoc.IssuedPayments()
+	inEUR := func(amount string) TAmount {
+		return TAmount{Amount: eur(amount), Currency: "EUR"}
+	}
+	entries := []Entry{
+		{Status: "BOOK", Amount: inEUR("30"), CreditDebit: Credit, BookingDate: "2017-06-11"},
+		{Status: "BOOK", Amount: inEUR("12"), CreditDebit: Credit, BookingDate: "2017-06-13",
+			Batches: []EntryBatch{{MsgID: "MSGID", PaymentInfoID: issued[2].PaymentInfoID, TransactNo: 2}}},
+		{Status: "BOOK", Amount: inEUR("12"), CreditDebit: Credit, BookingDate: "2017-06-13",
+			Batches: []EntryBatch{{PaymentInfoID: issued[2].PaymentInfoID, TransactNo: 3}}},
+	}
+	var bookings []BookedTransaction
+	for i := range entries {
+		bookings = append(bookings, entries[i].Transactions()...)
+	}
+	res := Reconcile(issued, bookings)
+
+	if len(res.Batches) != 2 {
+		t.Fatal("Expected 2 batches", "got", res.Batches)
+	}
+	for i, ids := range [][]string{{"E2E-1", "E2E-2"}, {"E2E-3", "E2E-4"}} {
+		batch := res.Batches[i]
+		if batch.PaymentInfoID != issued[2*i].PaymentInfoID || len(batch.Issued) != 2 || batch.Issued[0].EndToEndID != ids[0] || batch.Issued[1].EndToEndID != ids[1] {
+			t.Error("Expected batch", issued[2*i].PaymentInfoID, ids, "got", batch)
+		}
+	}
+	if len(res.Matched) != 0 || len(res.Unmatched) != 0 {
+		t.Error("Expected no payment outside the batches", "got", res.Matched, res.Unmatched)
+	}
+	if len(res.Unexpected) != 1 || res.Unexpected[0].Amount.Amount.Cmp(eur("12")) != 0 {
+		t.Error("Expected the batch of 3 transactions unexpected", "got", res.Unexpected)
+	}
+}
+func TestReconcileDateWindow(t *testing.T) {
+	var ctDoc = &CreditTransfer{}
+	// executed on Friday 2017-06-09
+	if err := ctDoc.InitDoc("VIR201706", "PMT-1", "2017-06-07T22:45:03", "2017-06-09", "Emitter Name", "FR1420041010050500013M02606", "BKAUATWW", "DE", "some street", "some city"); err != nil {
+		t.Fatal("Expected InitDoc return nil", "got", err)
+	}
+	if err := ctDoc.AddTransaction("F1", eur("99"), "EUR", "DEF Electronics", "GB29NWBK60161331926819", "BKAUATWW", "Cables"); err != nil {
+		t.Error("Expected AddTransaction return nil", "got", err)
+	}
+	var ddDoc = &DirectDebit{}
+	if err := ddDoc.InitDoc("MSGID", "PMT", "2017-06-07T14:39:33", "2017-06-09", "Emitter Name", "FR1420041010050500013M02606", "BKAUATWW", "DE98ZZZ09999999999", "DE", "some street", "some city"); err != nil {
+		t.Fatal("Expected InitDoc return nil", "got", err)
+	}
+	if err := ddDoc.AddTransaction("E2E-1", eur("7"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-1", "2017-01-01", SequenceFirst, ""); err != nil {
+		t.Error("Expected AddTransaction return nil", "got", err)
+	}
+	issued := append(ctDoc.IssuedPayments(), ddDoc.IssuedPayments()...)
+	inEUR := func(amount string) TAmount {
+		return TAmount{Amount: eur(amount), Currency: "EUR"}
+	}
+	bookedOn := func(date string, d EntryDetails) []BookedTransaction {
+		return []BookedTransaction{{EntryDetails: d, Entry: &Entry{Status: "BOOK", BookingDate: date, ValueDate: date}}}
+	}
+
+	// booked on Monday, the next business day
+	nextDay := bookedOn("2017-06-12", EntryDetails{Amount: inEUR("99"), CreditDebit: Debit})
+	if res := Reconcile(issued, nextDay); len(res.Matched) != 1 || res.Matched[0].Issued.EndToEndID != "F1" {
+		t.Error("Expected F1 matched when booked the next business day", "got", res.Matched, res.Unexpected)
+	}
+	if res := ReconcileWith(ReconcileOptions{}, issued, nextDay); len(res.Matched) != 0 || len(res.Unexpected) != 1 {
+		t.Error("Expected the booking unexpected without date window", "got", res.Matched, res.Unexpected)
+	}
+	later := bookedOn("2017-06-14", EntryDetails{Amount: inEUR("99"), CreditDebit: Debit})
+	if res := Reconcile(issued, later); len(res.Matched) != 0 || len(res.Unexpected) != 1 {
+		t.Error("Expected a booking 3 business days later unexpected", "got", res.Matched, res.Unexpected)
+	}
+	if res := ReconcileWith(ReconcileOptions{BusinessDays: 3}, issued, later); len(res.Matched) != 1 {
+		t.Error("Expected F1 matched within 3 business days", "got", res.Matched, res.Unexpected)
+	}
+
+	// same mandate and amount in the other direction
+	debited := bookedOn("2017-06-09", EntryDetails{MandateID: "MNDT-1", Amount: inEUR("7"), CreditDebit: Debit})
+	if res := Reconcile(issued, debited); len(res.Matched) != 0 || len(res.Unexpected) != 1 {
+		t.Error("Expected a debit unexpected for a collected mandate", "got", res.Matched, res.Unexpected)
+	}
+}
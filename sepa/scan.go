@@ -0,0 +1,605 @@
+package sepa
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// scanDocument reads the message element of a document, the first child of its root, and calls child with each of
+// its children, which reads or skips it
+func scanDocument(d *xml.Decoder, message string, child func(start xml.StartElement) error) error {
+	inMessage := false
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if !inMessage {
+				if t.Name.Local != message {
+					return errors.New("unexpected element " + t.Name.Local + ", expected " + message)
+				}
+				inMessage = true
+				continue
+			}
+			if err := child(t); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			if !inMessage {
+				return errors.New("missing " + message)
+			}
+			return nil
+		}
+	}
+}
+
+// scanItems reads the children of an element up to its end. The children named itemName are passed one at a time to
+// item, which decodes them. The children before the first of them are gathered into a document of their own,
+// passed to header with the names of these children before the first item is read.
+func scanItems(d *xml.Decoder, itemName string, header func(data []byte, children map[string]bool) error,
+	item func(start xml.StartElement) error) error {
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	if err := enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "Header"}}); err != nil {
+		return err
+	}
+	children := map[string]bool{}
+	headerRead := false
+	readHeader := func() error {
+		if headerRead {
+			return nil
+		}
+		headerRead = true
+		if err := enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "Header"}}); err != nil {
+			return err
+		}
+		if err := enc.Flush(); err != nil {
+			return err
+		}
+		return header(buf.Bytes(), children)
+	}
+
+	depth := 0
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 && t.Name.Local == itemName {
+				if err := readHeader(); err != nil {
+					return err
+				}
+				if err := item(t); err != nil {
+					return err
+				}
+				continue
+			}
+			if headerRead {
+				// elements after the items, such as SplmtryData, are not read
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if depth == 0 {
+				children[t.Name.Local] = true
+			}
+			depth++
+			if err := enc.EncodeToken(withoutNamespace(t)); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			if depth == 0 {
+				return readHeader()
+			}
+			depth--
+			if err := enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: t.Name.Local}}); err != nil {
+				return err
+			}
+		case xml.CharData:
+			if !headerRead && depth > 0 {
+				if err := enc.EncodeToken(t); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// withoutNamespace returns a start element without the namespace the decoder resolved and without xmlns attributes,
+// the layouts match local names only
+func withoutNamespace(start xml.StartElement) xml.StartElement {
+	s := xml.StartElement{Name: xml.Name{Local: start.Name.Local}}
+	for _, a := range start.Attr {
+		if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" {
+			continue
+		}
+		s.Attr = append(s.Attr, xml.Attr{Name: xml.Name{Local: a.Name.Local}, Value: a.Value})
+	}
+	return s
+}
+
+// scanTotals adds up the transactions read against the totals declared in a group or group header
+type scanTotals struct {
+	what       string
+	transactNo int
+	ctrlSum    Amount
+	// hasCtrlSum is false when the optional CtrlSum is missing, the transactions are only counted then
+	hasCtrlSum bool
+	n          int
+	sum        Amount
+}
+
+// add counts a transaction read
+func (t *scanTotals) add(amount Amount) error {
+	sum, err := t.sum.Add(amount)
+	if err != nil {
+		return err
+	}
+	t.n++
+	t.sum = sum
+	return nil
+}
+
+// check returns an error if the transactions read don't add up to the declared totals
+func (t *scanTotals) check() error {
+	ctrlSum := t.ctrlSum
+	if !t.hasCtrlSum {
+		ctrlSum = t.sum
+	}
+	return checkTotals(t.what, t.transactNo, ctrlSum, "read", t.n, t.sum)
+}
+
+// scanHeader is the layout of a pain group header (GrpHdr) read on its own
+type scanHeader struct {
+	MsgID        string      `xml:"MsgId"`
+	CreationDate DateTime    `xml:"CreDtTm"`
+	TransactNo   int         `xml:"NbOfTxs"`
+	CtrlSum      *Amount     `xml:"CtrlSum"`
+	EmitterName  string      `xml:"InitgPty>Nm"`
+	EmitterID    *partyIDXML `xml:"InitgPty>Id"`
+}
+
+// totals returns the totals declared by the group header
+func (h *scanHeader) totals() *scanTotals {
+	t := &scanTotals{what: "group header", transactNo: h.TransactNo}
+	if h.CtrlSum != nil {
+		t.ctrlSum, t.hasCtrlSum = *h.CtrlSum, true
+	}
+	return t
+}
+
+// CreditTransferHandler receives the parts of a credit transfer read by ScanCreditTransfer, nil functions are skipped
+type CreditTransferHandler struct {
+	// Header receives the document with its group header, without groups
+	Header func(doc *CreditTransfer) error
+	// PaymentInfo receives every group without its transactions
+	PaymentInfo func(p *CreditPaymentInfo) error
+	// Transaction receives every transaction with its group
+	Transaction func(p *CreditPaymentInfo, t CreditTransaction) error
+}
+
+// ScanCreditTransfer reads a pain.001 document in any supported schema version one transaction at a time, so that
+// memory stays flat however large the document is. The transaction numbers and control sums of every group and of
+// the group header are checked against the transactions read, once the group or the document is read.
+func ScanCreditTransfer(r io.Reader, h CreditTransferHandler) error {
+	d := xml.NewDecoder(r)
+	start, err := rootElement(d)
+	if err != nil {
+		return err
+	}
+	xsiLoc, ns, xsi := documentAttrs(start)
+	version := versionOf(ns)
+	if err := checkVersion(version, Pain001V03, Pain001V09); err != nil {
+		return err
+	}
+	doc := &CreditTransfer{XMLXsiLoc: xsiLoc, XMLNs: ns, XMLXsi: xsi}
+	if doc.XMLXsiLoc == "" {
+		doc.XMLXsiLoc = schemaLocation(version)
+	}
+
+	var header *scanTotals
+	err = scanDocument(d, "CstmrCdtTrfInitn", func(child xml.StartElement) error {
+		switch child.Name.Local {
+		case "GrpHdr":
+			grpHdr := &scanHeader{}
+			if err := d.DecodeElement(grpHdr, &child); err != nil {
+				return err
+			}
+			doc.GroupHeaderMsgID = grpHdr.MsgID
+			doc.GroupHeaderCreateDate = grpHdr.CreationDate
+			doc.GroupHeaderTransactNo = grpHdr.TransactNo
+			if grpHdr.CtrlSum != nil {
+				doc.GroupHeaderCtrlSum = *grpHdr.CtrlSum
+			}
+			doc.GroupHeaderEmitterName = grpHdr.EmitterName
+			doc.GroupHeaderEmitterID = grpHdr.EmitterID.partyIDRef()
+			header = grpHdr.totals()
+			if h.Header != nil {
+				return h.Header(doc)
+			}
+			return nil
+		case "PmtInf":
+			if header == nil {
+				return errors.New("missing group header")
+			}
+			var p CreditPaymentInfo
+			var group *scanTotals
+			err := scanItems(d, "CdtTrfTxInf", func(data []byte, children map[string]bool) error {
+				if version == Pain001V09 {
+					v09 := &creditPaymentInfoV09{}
+					if err := xml.Unmarshal(data, v09); err != nil {
+						return err
+					}
+					p = v09.creditPaymentInfo()
+				} else if err := xml.Unmarshal(data, &p); err != nil {
+					return err
+				}
+				group = &scanTotals{what: "payment info " + p.PaymentInfoID, transactNo: p.PaymentInfoTransactNo,
+					ctrlSum: p.PaymentInfoCtrlSum, hasCtrlSum: children["CtrlSum"]}
+				if h.PaymentInfo != nil {
+					return h.PaymentInfo(&p)
+				}
+				return nil
+			}, func(item xml.StartElement) error {
+				var t CreditTransaction
+				if version == Pain001V09 {
+					v09 := creditTransactionV09{}
+					if err := d.DecodeElement(&v09, &item); err != nil {
+						return err
+					}
+					t = v09.creditTransaction()
+				} else if err := d.DecodeElement(&t, &item); err != nil {
+					return err
+				}
+				if err := group.add(t.TransactAmount.Amount); err != nil {
+					return err
+				}
+				if err := header.add(t.TransactAmount.Amount); err != nil {
+					return err
+				}
+				if h.Transaction != nil {
+					return h.Transaction(&p, t)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			return group.check()
+		}
+		return d.Skip()
+	})
+	if err != nil {
+		return err
+	}
+	if header == nil {
+		return errors.New("missing group header")
+	}
+	return header.check()
+}
+
+// DirectDebitHandler receives the parts of a direct debit read by ScanDirectDebit, nil functions are skipped
+type DirectDebitHandler struct {
+	// Header receives the document with its group header, without groups
+	Header func(doc *DirectDebit) error
+	// PaymentInfo receives every group without its transactions
+	PaymentInfo func(p *DebitPaymentInfo) error
+	// Transaction receives every transaction with its group
+	Transaction func(p *DebitPaymentInfo, t DebitTransaction) error
+}
+
+// ScanDirectDebit reads a pain.008 document in any supported schema version one transaction at a time, so that
+// memory stays flat however large the document is. The transaction numbers and control sums of every group and of
+// the group header are checked against the transactions read, once the group or the document is read.
+func ScanDirectDebit(r io.Reader, h DirectDebitHandler) error {
+	d := xml.NewDecoder(r)
+	start, err := rootElement(d)
+	if err != nil {
+		return err
+	}
+	xsiLoc, ns, xsi := documentAttrs(start)
+	version := versionOf(ns)
+	if err := checkVersion(version, Pain008DKV02, Pain008V02, Pain008V08); err != nil {
+		return err
+	}
+	doc := &DirectDebit{XMLXsiLoc: xsiLoc, XMLNs: ns, XMLXsi: xsi}
+	if doc.XMLXsiLoc == "" {
+		doc.XMLXsiLoc = schemaLocation(version)
+	}
+
+	var header *scanTotals
+	err = scanDocument(d, "CstmrDrctDbtInitn", func(child xml.StartElement) error {
+		switch child.Name.Local {
+		case "GrpHdr":
+			grpHdr := &scanHeader{}
+			if err := d.DecodeElement(grpHdr, &child); err != nil {
+				return err
+			}
+			doc.GroupHeaderMsgID = grpHdr.MsgID
+			doc.GroupHeaderCreateDate = grpHdr.CreationDate
+			doc.GroupHeaderTransactNo = grpHdr.TransactNo
+			if grpHdr.CtrlSum != nil {
+				doc.GroupHeaderCtrlSum = *grpHdr.CtrlSum
+			}
+			doc.GroupHeaderEmitterName = grpHdr.EmitterName
+			doc.GroupHeaderEmitterID = grpHdr.EmitterID.partyIDRef()
+			header = grpHdr.totals()
+			if h.Header != nil {
+				return h.Header(doc)
+			}
+			return nil
+		case "PmtInf":
+			if header == nil {
+				return errors.New("missing group header")
+			}
+			var p DebitPaymentInfo
+			var group *scanTotals
+			err := scanItems(d, "DrctDbtTxInf", func(data []byte, children map[string]bool) error {
+				if version == Pain008V08 {
+					v08 := &debitPaymentInfoV08{}
+					if err := xml.Unmarshal(data, v08); err != nil {
+						return err
+					}
+					p = v08.debitPaymentInfo()
+				} else if err := xml.Unmarshal(data, &p); err != nil {
+					return err
+				}
+				group = &scanTotals{what: "payment info " + p.PaymentInfoID, transactNo: p.PaymentInfoTransactNo,
+					ctrlSum: p.PaymentInfoCtrlSum, hasCtrlSum: children["CtrlSum"]}
+				if h.PaymentInfo != nil {
+					return h.PaymentInfo(&p)
+				}
+				return nil
+			}, func(item xml.StartElement) error {
+				var t DebitTransaction
+				if version == Pain008V08 {
+					v08 := debitTransactionV08{}
+					if err := d.DecodeElement(&v08, &item); err != nil {
+						return err
+					}
+					t = v08.debitTransaction()
+				} else if err := d.DecodeElement(&t, &item); err != nil {
+					return err
+				}
+				if err := group.add(t.TransactAmount.Amount); err != nil {
+					return err
+				}
+				if err := header.add(t.TransactAmount.Amount); err != nil {
+					return err
+				}
+				if h.Transaction != nil {
+					return h.Transaction(&p, t)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			return group.check()
+		}
+		return d.Skip()
+	})
+	if err != nil {
+		return err
+	}
+	if header == nil {
+		return errors.New("missing group header")
+	}
+	return header.check()
+}
+
+// camtHeader is the layout of a camt group header (GrpHdr) read on its own
+type camtHeader struct {
+	MsgID        string `xml:"MsgId"`
+	CreationDate string `xml:"CreDtTm"`
+}
+
+// entryTotals adds up the entries of a statement or notification read against its transaction summary and balances
+type entryTotals struct {
+	what   string
+	report *camtAccountReport
+	n      int
+	// sum adds up the amounts of all entries, net the booked entries, debits counting negative
+	sum Amount
+	net Amount
+}
+
+// add counts an entry read
+func (t *entryTotals) add(e *Entry) error {
+	sum, err := t.sum.Add(e.Amount.Amount)
+	if err != nil {
+		return err
+	}
+	net := t.net
+	if e.Status == "" || e.Status == "BOOK" {
+		amount := e.Amount.Amount
+		// a reversal books the opposite of its indicator
+		if (e.CreditDebit == Debit) != e.Reversal {
+			amount = amount.Neg()
+		}
+		if net, err = net.Add(amount); err != nil {
+			return err
+		}
+	}
+	t.n++
+	t.sum, t.net = sum, net
+	return nil
+}
+
+// check compares the entries read with the number and sum of entries of the transaction summary and, when the
+// report has them, the closing balance with the opening balance plus the booked entries
+func (t *entryTotals) check() error {
+	r := t.report
+	if r.EntryCount != nil && *r.EntryCount != t.n {
+		return errors.New(t.what + " declares " + strconv.Itoa(*r.EntryCount) + " entries, " + strconv.Itoa(t.n) + " read")
+	}
+	if r.EntrySum != nil && r.EntrySum.Cmp(t.sum) != 0 {
+		return errors.New(t.what + " declares entries for " + r.EntrySum.String() + ", " + t.sum.String() + " read")
+	}
+	var opening, closing *camtBalance
+	for i := range r.Balances {
+		switch b := &r.Balances[i]; b.Type {
+		case BalanceOpening, "PRCD":
+			if opening == nil {
+				opening = b
+			}
+		case BalanceClosing:
+			closing = b
+		}
+	}
+	if opening == nil || closing == nil {
+		return nil
+	}
+	expected, err := opening.signed().Add(t.net)
+	if err != nil {
+		return err
+	}
+	if expected.Cmp(closing.signed()) != 0 {
+		return errors.New(t.what + " closing balance " + closing.signed().String() + ", opening balance and booked entries add up to " +
+			expected.String())
+	}
+	return nil
+}
+
+// signed returns the amount of the balance, negative for a debit balance
+func (b *camtBalance) signed() Amount {
+	if b.CreditDebit == Debit {
+		return b.Amount.Amount.Neg()
+	}
+	return b.Amount.Amount
+}
+
+// scanCamt reads a camt document one entry at a time, passing the group header to header, every report
+// (Stmt or Ntfctn, named what in errors) without entries to report and every entry to entry
+func scanCamt(r io.Reader, message string, reportName string, what string, versions []string, header func(version string, h *camtHeader) error,
+	report func(r *camtAccountReport) error, entry func(e Entry) error) error {
+	d := xml.NewDecoder(r)
+	start, err := rootElement(d)
+	if err != nil {
+		return err
+	}
+	version := versionOf(start.Name.Space)
+	if err := checkVersion(version, versions...); err != nil {
+		return err
+	}
+	return scanDocument(d, message, func(child xml.StartElement) error {
+		switch child.Name.Local {
+		case "GrpHdr":
+			grpHdr := &camtHeader{}
+			if err := d.DecodeElement(grpHdr, &child); err != nil {
+				return err
+			}
+			return header(version, grpHdr)
+		case reportName:
+			layout := &camtAccountReport{}
+			totals := &entryTotals{report: layout}
+			err := scanItems(d, "Ntry", func(data []byte, _ map[string]bool) error {
+				if err := xml.Unmarshal(data, layout); err != nil {
+					return err
+				}
+				totals.what = what + " " + layout.ID
+				return report(layout)
+			}, func(item xml.StartElement) error {
+				var e camtEntry
+				if err := d.DecodeElement(&e, &item); err != nil {
+					return err
+				}
+				booked := e.entry()
+				if err := totals.add(&booked); err != nil {
+					return err
+				}
+				return entry(booked)
+			})
+			if err != nil {
+				return err
+			}
+			return totals.check()
+		}
+		return d.Skip()
+	})
+}
+
+// BankStatementHandler receives the parts of a bank statement read by ScanBankStatement, nil functions are skipped
+type BankStatementHandler struct {
+	// Header receives the document with its group header, without statements
+	Header func(doc *BankStatement) error
+	// Statement receives every statement with its balances, without entries
+	Statement func(s *AccountStatement) error
+	// Entry receives every entry with its statement
+	Entry func(s *AccountStatement, e Entry) error
+}
+
+// ScanBankStatement reads a camt.053 document in any supported schema version one entry at a time, so that memory
+// stays flat however large the document is. The entries of every statement are checked against the number and sum of
+// entries of its transaction summary and against its opening and closing balances, once the statement is read.
+func ScanBankStatement(r io.Reader, h BankStatementHandler) error {
+	var stmt AccountStatement
+	return scanCamt(r, "BkToCstmrStmt", "Stmt", "statement", []string{Camt053V02, Camt053V04, Camt053V08},
+		func(version string, grpHdr *camtHeader) error {
+			if h.Header == nil {
+				return nil
+			}
+			return h.Header(&BankStatement{Version: version, MsgID: grpHdr.MsgID, CreationDate: grpHdr.CreationDate})
+		}, func(r *camtAccountReport) error {
+			stmt = r.accountStatement()
+			if h.Statement != nil {
+				return h.Statement(&stmt)
+			}
+			return nil
+		}, func(e Entry) error {
+			if h.Entry != nil {
+				return h.Entry(&stmt, e)
+			}
+			return nil
+		})
+}
+
+// BankNotificationHandler receives the parts of a bank notification read by ScanBankNotification,
+// nil functions are skipped
+type BankNotificationHandler struct {
+	// Header receives the document with its group header, without notifications
+	Header func(doc *BankNotification) error
+	// Notification receives every notification without entries
+	Notification func(n *AccountNotification) error
+	// Entry receives every entry with its notification
+	Entry func(n *AccountNotification, e Entry) error
+}
+
+// ScanBankNotification reads a camt.054 document in any supported schema version one entry at a time, so that memory
+// stays flat however large the document is. The entries of every notification are checked against the number and
+// sum of entries of its transaction summary, once the notification is read.
+func ScanBankNotification(r io.Reader, h BankNotificationHandler) error {
+	var ntfctn AccountNotification
+	return scanCamt(r, "BkToCstmrDbtCdtNtfctn", "Ntfctn", "notification", []string{Camt054V02, Camt054V04, Camt054V08},
+		func(version string, grpHdr *camtHeader) error {
+			if h.Header == nil {
+				return nil
+			}
+			return h.Header(&BankNotification{Version: version, MsgID: grpHdr.MsgID, CreationDate: grpHdr.CreationDate})
+		}, func(r *camtAccountReport) error {
+			ntfctn = r.accountNotification()
+			if h.Notification != nil {
+				return h.Notification(&ntfctn)
+			}
+			return nil
+		}, func(e Entry) error {
+			if h.Entry != nil {
+				return h.Entry(&ntfctn, e)
+			}
+			return nil
+		})
+}
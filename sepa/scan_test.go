@@ -0,0 +1,177 @@
+package sepa
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScanCreditTransfer(t *testing.T) {
+	for _, version := range []string{Pain001V03, Pain001V09} {
+		doc := splitTestTransfer(t, []string{"10", "20"}, []string{"30"})
+		if err := doc.SetVersion(version); err != nil {
+			t.Fatal("Expected SetVersion return nil", "got", err)
+		}
+		str, err := doc.PrettySerialize()
+		if err != nil {
+			t.Fatal("Expected PrettySerialize return nil", "got", err)
+		}
+		parsed, err := ParseCreditTransfer(bytes.NewReader(str))
+		if err != nil {
+			t.Fatal("Expected ParseCreditTransfer return nil", "got", err)
+		}
+
+		var header *CreditTransfer
+		var groups []CreditPaymentInfo
+		err = ScanCreditTransfer(bytes.NewReader(str), CreditTransferHandler{
+			Header: func(doc *CreditTransfer) error {
+				header = doc
+				return nil
+			},
+			PaymentInfo: func(p *CreditPaymentInfo) error {
+				groups = append(groups, *p)
+				return nil
+			},
+			Transaction: func(p *CreditPaymentInfo, tx CreditTransaction) error {
+				if p.PaymentInfoID != groups[len(groups)-1].PaymentInfoID {
+					t.Error("Expected", tx.TransactIDe2e, "in", groups[len(groups)-1].PaymentInfoID, "got", p.PaymentInfoID)
+				}
+				groups[len(groups)-1].PaymentTransactions = append(groups[len(groups)-1].PaymentTransactions, tx)
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatal("Expected ScanCreditTransfer return nil", "got", err)
+		}
+		if header.GroupHeaderMsgID != "MSGID" || header.GroupHeaderTransactNo != 3 || header.Version() != version {
+			t.Error("Expected the group header of MSGID", "got", header)
+		}
+		if !reflect.DeepEqual(groups, parsed.PaymentInfos) {
+			t.Error("Expected", parsed.PaymentInfos, "got", groups)
+		}
+	}
+
+	// control sums
+	doc := splitTestTransfer(t, []string{"10", "20"}, []string{"30"})
+	str, _ := doc.Serialize()
+	bad := strings.Replace(string(str), "<CtrlSum>30.00</CtrlSum>", "<CtrlSum>31.00</CtrlSum>", 1)
+	if err := ScanCreditTransfer(strings.NewReader(bad), CreditTransferHandler{}); err == nil ||
+		err.Error() != "payment info PMT-1 declares 2 transactions for 31.00, 2 read for 30.00" {
+		t.Error("Expected ScanCreditTransfer return an error for the control sum of PMT-1", "got", err)
+	}
+	bad = strings.Replace(string(str), "<NbOfTxs>3</NbOfTxs>", "<NbOfTxs>4</NbOfTxs>", 1)
+	if err := ScanCreditTransfer(strings.NewReader(bad), CreditTransferHandler{}); err == nil || !strings.HasPrefix(err.Error(), "group header") {
+		t.Error("Expected ScanCreditTransfer return an error for the transaction number of the group header", "got", err)
+	}
+	missing := strings.Replace(string(str), "<CtrlSum>30.00</CtrlSum>", "", 1)
+	if err := ScanCreditTransfer(strings.NewReader(missing), CreditTransferHandler{}); err != nil {
+		t.Error("Expected ScanCreditTransfer return nil without the optional control sum", "got", err)
+	}
+	if err := ScanCreditTransfer(bytes.NewReader(str[:len(str)-50]), CreditTransferHandler{}); err == nil {
+		t.Error("Expected ScanCreditTransfer return an error for a truncated document")
+	}
+
+	// stopped by the handler
+	n := 0
+	err := ScanCreditTransfer(bytes.NewReader(str), CreditTransferHandler{Transaction: func(p *CreditPaymentInfo, tx CreditTransaction) error {
+		n++
+		return errors.New("stop")
+	}})
+	if err == nil || err.Error() != "stop" || n != 1 {
+		t.Error("Expected ScanCreditTransfer stop at the first transaction", "got", err, n)
+	}
+}
+func TestScanDirectDebit(t *testing.T) {
+	for _, version := range []string{Pain008DKV02, Pain008V08} {
+		doc, err := NewDirectDebit(DirectDebitConfig{Version: version, MsgID: "MSGID", PaymentInfoID: "PMT",
+			CreationDate: at("2017-06-07T14:39:33"), CollectionDate: day("2017-06-11"), Initiator: testEmitter,
+			CreditorAccount: testEmitterAccount, CreditorSchemeID: "DE98ZZZ09999999999"})
+		if err != nil {
+			t.Fatal("Expected NewDirectDebit return nil", "got", err)
+		}
+		for i, sequenceType := range []string{SequenceFirst, SequenceRecurring, SequenceFirst} {
+			id := "E2E-" + string(rune('1'+i))
+			if err := doc.AddTransaction(id, eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "", "Invoice", "MNDT-"+id, "2017-01-01", sequenceType, ""); err != nil {
+				t.Fatal("Expected AddTransaction return nil", "got", err)
+			}
+		}
+		str, err := doc.Serialize()
+		if err != nil {
+			t.Fatal("Expected Serialize return nil", "got", err)
+		}
+		parsed, err := ParseDirectDebit(bytes.NewReader(str))
+		if err != nil {
+			t.Fatal("Expected ParseDirectDebit return nil", "got", err)
+		}
+
+		var groups []DebitPaymentInfo
+		err = ScanDirectDebit(bytes.NewReader(str), DirectDebitHandler{
+			PaymentInfo: func(p *DebitPaymentInfo) error {
+				groups = append(groups, *p)
+				return nil
+			},
+			Transaction: func(p *DebitPaymentInfo, tx DebitTransaction) error {
+				groups[len(groups)-1].PaymentTransactions = append(groups[len(groups)-1].PaymentTransactions, tx)
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatal("Expected ScanDirectDebit return nil", "got", err)
+		}
+		if !reflect.DeepEqual(groups, parsed.PaymentInfos) {
+			t.Error("Expected", parsed.PaymentInfos, "got", groups)
+		}
+	}
+}
+func TestScanBankStatement(t *testing.T) {
+	for _, doc := range []string{camt053V02Doc, camt053V08Doc} {
+		parsed, err := ParseBankStatement(strings.NewReader(doc))
+		if err != nil {
+			t.Fatal("Expected ParseBankStatement return nil", "got", err)
+		}
+		var msgID string
+		var statements []AccountStatement
+		err = ScanBankStatement(strings.NewReader(doc), BankStatementHandler{
+			Header: func(doc *BankStatement) error {
+				msgID = doc.MsgID
+				return nil
+			},
+			Statement: func(s *AccountStatement) error {
+				statements = append(statements, *s)
+				return nil
+			},
+			Entry: func(s *AccountStatement, e Entry) error {
+				statements[len(statements)-1].Entries = append(statements[len(statements)-1].Entries, e)
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatal("Expected ScanBankStatement return nil", "got", err)
+		}
+		if msgID != parsed.MsgID || !reflect.DeepEqual(statements, parsed.Statements) {
+			t.Error("Expected", parsed.Statements, "got", statements)
+		}
+	}
+
+	// balances and transaction summary
+	bad := strings.Replace(camt053V02Doc, "1020.50", "1020.00", 1)
+	if err := ScanBankStatement(strings.NewReader(bad), BankStatementHandler{}); err == nil ||
+		err.Error() != "statement STMT-1 closing balance 1020.00, opening balance and booked entries add up to 1020.50" {
+		t.Error("Expected ScanBankStatement return an error for the closing balance", "got", err)
+	}
+	summary := "<TxsSummry><TtlNtries><NbOfNtries>3</NbOfNtries><Sum>40.50</Sum></TtlNtries></TxsSummry><Ntry>"
+	bad = strings.Replace(camt053V02Doc, "<Ntry>", summary, 1)
+	if err := ScanBankStatement(strings.NewReader(bad), BankStatementHandler{}); err == nil ||
+		err.Error() != "statement STMT-1 declares 3 entries, 2 read" {
+		t.Error("Expected ScanBankStatement return an error for the number of entries", "got", err)
+	}
+	good := strings.Replace(bad, "<NbOfNtries>3</NbOfNtries>", "<NbOfNtries>2</NbOfNtries>", 1)
+	if err := ScanBankStatement(strings.NewReader(good), BankStatementHandler{}); err != nil {
+		t.Error("Expected ScanBankStatement return nil", "got", err)
+	}
+	if err := ScanBankNotification(strings.NewReader(camt053V02Doc), BankNotificationHandler{}); err == nil {
+		t.Error("Expected ScanBankNotification return an error for a statement")
+	}
+}
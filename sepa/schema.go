@@ -0,0 +1,727 @@
+package sepa
+
+import (
+	"bytes"
+	"embed"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+//go:embed xsd/*.xsd
+var xsdFiles embed.FS
+
+// schemaFile is the XSD of every supported schema version, as embedded from the xsd directory
+var schemaFile = map[string]string{
+	Pain001V03:   "xsd/pain.001.001.03.xsd",
+	Pain001V09:   "xsd/pain.001.001.09.xsd",
+	Pain008DKV02: "xsd/pain.008.003.02.xsd",
+	Pain008V02:   "xsd/pain.008.001.02.xsd",
+	Pain008V08:   "xsd/pain.008.001.08.xsd",
+}
+
+// schemas holds the embedded XSDs parsed so far
+var schemas = struct {
+	sync.Mutex
+	m map[string]*xsdSchema
+}{m: map[string]*xsdSchema{}}
+
+// SchemaError is a violation of the XSD of a document
+type SchemaError struct {
+	// Line is the line of the element, counted from 1
+	Line int
+	// Path is the path of the element, "/Document/CstmrCdtTrfInitn/PmtInf[2]/DbtrAcct/Id/IBAN"
+	Path    string
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	return "line " + strconv.Itoa(e.Line) + " " + e.Path + " : " + e.Message
+}
+
+// SchemaErrors lists the violations of the XSD of a document in document order
+type SchemaErrors []SchemaError
+
+func (e SchemaErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks the document against the embedded XSD of its schema version, see ValidateXML.
+// The lines of the violations are the ones of the document written by PrettySerialize.
+func (doc *CreditTransfer) Validate() error {
+	b, err := doc.PrettySerialize()
+	if err != nil {
+		return err
+	}
+	return ValidateXML(bytes.NewReader(b))
+}
+
+// Validate checks the document against the embedded XSD of its schema version, see ValidateXML.
+// The lines of the violations are the ones of the document written by PrettySerialize.
+func (doc *DirectDebit) Validate() error {
+	b, err := doc.PrettySerialize()
+	if err != nil {
+		return err
+	}
+	return ValidateXML(bytes.NewReader(b))
+}
+
+// ValidateXML checks a document against the embedded XSD of the schema version of its namespace : element order,
+// cardinality, patterns, enumerations, lengths, digits and bounds. The violations are returned as SchemaErrors.
+func ValidateXML(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	root, err := readXMLTree(data)
+	if err != nil {
+		return err
+	}
+	s, err := loadSchema(versionOf(root.name.Space))
+	if err != nil {
+		return err
+	}
+	v := &schemaValidator{s: s}
+	path := "/" + root.name.Local
+	if root.name.Local != s.root.name {
+		v.fail(root, path, "unexpected root element, expected "+s.root.name)
+	} else {
+		v.element(root, path, s.root.typ)
+	}
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// loadSchema returns the parsed embedded XSD of a schema version
+func loadSchema(version string) (*xsdSchema, error) {
+	file, ok := schemaFile[version]
+	if !ok {
+		return nil, errors.New("no embedded schema for " + version + ", validate it with the XSD of your bank")
+	}
+	schemas.Lock()
+	defer schemas.Unlock()
+	if s := schemas.m[version]; s != nil {
+		return s, nil
+	}
+	data, err := xsdFiles.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	s, err := parseSchema(data)
+	if err != nil {
+		return nil, errors.New(file + " : " + err.Error())
+	}
+	schemas.m[version] = s
+	return s, nil
+}
+
+// xsdNode is any element of an XSD file
+type xsdNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []xsdNode  `xml:",any"`
+}
+
+func (n *xsdNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (n *xsdNode) child(name string) *xsdNode {
+	for i := range n.Children {
+		if n.Children[i].XMLName.Local == name {
+			return &n.Children[i]
+		}
+	}
+	return nil
+}
+
+// xsdSchema is the subset of XSD used by the ISO 20022 schemas : named types, sequences and choices of elements,
+// wildcards, simple content with attributes and restrictions of built-in types by facets
+type xsdSchema struct {
+	namespace string
+	root      xsdParticle
+	complex   map[string]*xsdComplexType
+	simple    map[string]*xsdSimpleType
+}
+
+// xsdParticle is an element, wildcard, sequence or choice with its occurrences, max is -1 for unbounded.
+// The elements matched by a wildcard are not checked, as for the lax processing of the supplementary data.
+type xsdParticle struct {
+	kind     string
+	name     string
+	typ      string
+	min, max int
+	children []*xsdParticle
+}
+
+// xsdComplexType has either element content or the simple content of base with attributes
+type xsdComplexType struct {
+	content *xsdParticle
+	base    string
+	attrs   []xsdAttribute
+}
+
+type xsdAttribute struct {
+	name     string
+	typ      string
+	required bool
+}
+
+// xsdSimpleType restricts base by facets, a nil facet sets no limit
+type xsdSimpleType struct {
+	base           string
+	enumeration    []string
+	patterns       []*regexp.Regexp
+	minLength      *int
+	maxLength      *int
+	totalDigits    *int
+	fractionDigits *int
+	minInclusive   *big.Rat
+	maxInclusive   *big.Rat
+}
+
+func parseSchema(data []byte) (*xsdSchema, error) {
+	var root xsdNode
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	s := &xsdSchema{
+		namespace: root.attr("targetNamespace"),
+		complex:   map[string]*xsdComplexType{},
+		simple:    map[string]*xsdSimpleType{},
+	}
+	for i := range root.Children {
+		n := &root.Children[i]
+		var err error
+		switch n.XMLName.Local {
+		case "element":
+			s.root = xsdParticle{kind: "element", name: n.attr("name"), typ: n.attr("type"), min: 1, max: 1}
+		case "complexType":
+			s.complex[n.attr("name")], err = parseComplexType(n)
+		case "simpleType":
+			s.simple[n.attr("name")], err = parseSimpleType(n)
+		}
+		if err != nil {
+			return nil, errors.New(n.attr("name") + " : " + err.Error())
+		}
+	}
+	return s, nil
+}
+
+func parseComplexType(n *xsdNode) (*xsdComplexType, error) {
+	c := &xsdComplexType{}
+	for i := range n.Children {
+		child := &n.Children[i]
+		switch child.XMLName.Local {
+		case "sequence", "choice":
+			p, err := parseParticle(child)
+			if err != nil {
+				return nil, err
+			}
+			c.content = p
+		case "simpleContent":
+			ext := child.child("extension")
+			if ext == nil {
+				return nil, errors.New("unsupported simple content")
+			}
+			c.base = ext.attr("base")
+			for _, a := range ext.Children {
+				if a.XMLName.Local == "attribute" {
+					c.attrs = append(c.attrs, xsdAttribute{name: a.attr("name"), typ: a.attr("type"), required: a.attr("use") == "required"})
+				}
+			}
+		case "annotation":
+		default:
+			return nil, errors.New("unsupported " + child.XMLName.Local)
+		}
+	}
+	return c, nil
+}
+
+func parseParticle(n *xsdNode) (*xsdParticle, error) {
+	p := &xsdParticle{kind: n.XMLName.Local, name: n.attr("name"), typ: n.attr("type"), min: 1, max: 1}
+	var err error
+	if v := n.attr("minOccurs"); v != "" {
+		if p.min, err = strconv.Atoi(v); err != nil {
+			return nil, err
+		}
+	}
+	if v := n.attr("maxOccurs"); v == "unbounded" {
+		p.max = -1
+	} else if v != "" {
+		if p.max, err = strconv.Atoi(v); err != nil {
+			return nil, err
+		}
+	}
+	if p.kind == "element" || p.kind == "any" {
+		return p, nil
+	}
+	for i := range n.Children {
+		child := &n.Children[i]
+		switch child.XMLName.Local {
+		case "element", "any", "sequence", "choice":
+			c, err := parseParticle(child)
+			if err != nil {
+				return nil, err
+			}
+			p.children = append(p.children, c)
+		case "annotation":
+		default:
+			return nil, errors.New("unsupported " + child.XMLName.Local)
+		}
+	}
+	return p, nil
+}
+
+func parseSimpleType(n *xsdNode) (*xsdSimpleType, error) {
+	r := n.child("restriction")
+	if r == nil {
+		return nil, errors.New("unsupported simple type")
+	}
+	t := &xsdSimpleType{base: r.attr("base")}
+	for _, f := range r.Children {
+		value := f.attr("value")
+		var err error
+		switch f.XMLName.Local {
+		case "enumeration":
+			t.enumeration = append(t.enumeration, value)
+		case "pattern":
+			var re *regexp.Regexp
+			if re, err = regexp.Compile("^(?:" + value + ")$"); err == nil {
+				t.patterns = append(t.patterns, re)
+			}
+		case "minLength":
+			t.minLength, err = intFacet(value)
+		case "maxLength":
+			t.maxLength, err = intFacet(value)
+		case "length":
+			if t.minLength, err = intFacet(value); err == nil {
+				t.maxLength = t.minLength
+			}
+		case "totalDigits":
+			t.totalDigits, err = intFacet(value)
+		case "fractionDigits":
+			t.fractionDigits, err = intFacet(value)
+		case "minInclusive":
+			t.minInclusive, err = ratFacet(value)
+		case "maxInclusive":
+			t.maxInclusive, err = ratFacet(value)
+		case "annotation":
+		default:
+			err = errors.New("unsupported facet " + f.XMLName.Local)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func intFacet(value string) (*int, error) {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+func ratFacet(value string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, errors.New("invalid bound " + value)
+	}
+	return r, nil
+}
+
+// xmlElement is an element of a document being validated
+type xmlElement struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	children []*xmlElement
+	text     []byte
+	line     int
+}
+
+// readXMLTree reads a document into a tree of elements with their lines
+func readXMLTree(data []byte) (*xmlElement, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	var root *xmlElement
+	var stack []*xmlElement
+	line, counted := 1, 0
+	for {
+		offset := int(d.InputOffset())
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			line += bytes.Count(data[counted:offset], []byte("\n"))
+			counted = offset
+			e := &xmlElement{name: t.Name, attrs: t.Attr, line: line}
+			if len(stack) == 0 {
+				root = e
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, e)
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				e := stack[len(stack)-1]
+				e.text = append(e.text, t...)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("empty xml document")
+	}
+	return root, nil
+}
+
+// schemaValidator checks the elements of a document against a schema, gathering the violations
+type schemaValidator struct {
+	s    *xsdSchema
+	errs SchemaErrors
+}
+
+func (v *schemaValidator) fail(e *xmlElement, path string, message string) {
+	v.errs = append(v.errs, SchemaError{Line: e.line, Path: path, Message: message})
+}
+
+// element checks an element and its descendants against its type
+func (v *schemaValidator) element(e *xmlElement, path string, typ string) {
+	if e.name.Space != v.s.namespace {
+		v.fail(e, path, "element in namespace "+e.name.Space+", expected "+v.s.namespace)
+	}
+	c := v.s.complex[typ]
+	if c == nil || c.content == nil {
+		base := typ
+		var attrs []xsdAttribute
+		if c != nil {
+			base, attrs = c.base, c.attrs
+		}
+		v.attributes(e, path, attrs)
+		if len(e.children) > 0 {
+			v.fail(e.children[0], path+"/"+e.children[0].name.Local, "unexpected element, "+e.name.Local+" has a value only")
+			return
+		}
+		if msg := v.value(string(e.text), base); msg != "" {
+			v.fail(e, path, msg)
+		}
+		return
+	}
+
+	v.attributes(e, path, c.attrs)
+	if strings.TrimSpace(string(e.text)) != "" {
+		v.fail(e, path, "unexpected text "+strconv.Quote(strings.TrimSpace(string(e.text))))
+	}
+	paths := childPaths(e, path)
+	types := make([]string, len(e.children))
+	v.content(e, path, paths, c.content, types)
+	for i, child := range e.children {
+		if types[i] != "" {
+			v.element(child, paths[i], types[i])
+		}
+	}
+}
+
+// childPaths returns the paths of the children of an element, indexed when their name repeats
+func childPaths(e *xmlElement, path string) []string {
+	count := map[string]int{}
+	for _, child := range e.children {
+		count[child.name.Local]++
+	}
+	seen := map[string]int{}
+	paths := make([]string, len(e.children))
+	for i, child := range e.children {
+		paths[i] = path + "/" + child.name.Local
+		if count[child.name.Local] > 1 {
+			seen[child.name.Local]++
+			paths[i] += "[" + strconv.Itoa(seen[child.name.Local]) + "]"
+		}
+	}
+	return paths
+}
+
+// attributes checks the attributes of an element against the declared ones,
+// namespace declarations and xsi attributes being allowed anywhere
+func (v *schemaValidator) attributes(e *xmlElement, path string, declared []xsdAttribute) {
+	found := map[string]bool{}
+	for _, a := range e.attrs {
+		if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" || a.Name.Space == xsiNamespace {
+			continue
+		}
+		var d *xsdAttribute
+		for i := range declared {
+			if declared[i].name == a.Name.Local && a.Name.Space == "" {
+				d = &declared[i]
+			}
+		}
+		if d == nil {
+			v.fail(e, path, "unexpected attribute "+a.Name.Local)
+			continue
+		}
+		found[d.name] = true
+		if msg := v.value(a.Value, d.typ); msg != "" {
+			v.fail(e, path+"/@"+d.name, msg)
+		}
+	}
+	for _, d := range declared {
+		if d.required && !found[d.name] {
+			v.fail(e, path, "missing attribute "+d.name)
+		}
+	}
+}
+
+// content matches the children of an element against its content model, setting the type of every child matched.
+// The particles of a top sequence are checked in turn so that every missing element is reported.
+func (v *schemaValidator) content(e *xmlElement, path string, paths []string, model *xsdParticle, types []string) {
+	particles := []*xsdParticle{model}
+	if model.kind == "sequence" && model.min == 1 && model.max == 1 {
+		particles = model.children
+	}
+	pos := 0
+	for _, p := range particles {
+		next, n, ok := v.matchOccurs(p, e.children, pos, types)
+		if !ok {
+			if next < len(e.children) {
+				v.fail(e.children[next], paths[next], "unexpected element, expected "+strings.Join(firstNames(p), " or "))
+			} else {
+				v.fail(e, path, "missing element "+strings.Join(firstNames(p), " or "))
+			}
+		}
+		if p.max >= 0 && n == p.max && next < len(e.children) {
+			if _, more := v.matchOnce(p, e.children, next, make([]string, len(types))); more {
+				child := e.children[next]
+				v.fail(child, paths[next], "more than "+strconv.Itoa(p.max)+" "+child.name.Local)
+				return
+			}
+		}
+		pos = next
+	}
+	if pos < len(e.children) {
+		v.fail(e.children[pos], paths[pos], "unexpected element")
+	}
+}
+
+// matchOccurs matches p as many times as it can from pos, returns the position after, the number of matches and
+// whether they reach minOccurs
+func (v *schemaValidator) matchOccurs(p *xsdParticle, children []*xmlElement, pos int, types []string) (int, int, bool) {
+	n := 0
+	for p.max < 0 || n < p.max {
+		next, ok := v.matchOnce(p, children, pos, types)
+		if !ok || next == pos {
+			break
+		}
+		pos = next
+		n++
+	}
+	return pos, n, n >= p.min
+}
+
+// matchOnce matches one occurrence of p from pos
+func (v *schemaValidator) matchOnce(p *xsdParticle, children []*xmlElement, pos int, types []string) (int, bool) {
+	switch p.kind {
+	case "element":
+		if pos < len(children) && children[pos].name.Local == p.name {
+			types[pos] = p.typ
+			return pos + 1, true
+		}
+		return pos, false
+	case "any":
+		if pos < len(children) {
+			return pos + 1, true
+		}
+		return pos, false
+	case "sequence":
+		next := pos
+		for _, c := range p.children {
+			var ok bool
+			if next, _, ok = v.matchOccurs(c, children, next, types); !ok {
+				return pos, false
+			}
+		}
+		return next, true
+	case "choice":
+		empty := false
+		for _, c := range p.children {
+			next, _, ok := v.matchOccurs(c, children, pos, types)
+			if ok && next > pos {
+				return next, true
+			}
+			empty = empty || ok
+		}
+		return pos, empty
+	}
+	return pos, false
+}
+
+// firstNames returns the elements a particle can start with
+func firstNames(p *xsdParticle) []string {
+	switch p.kind {
+	case "element":
+		return []string{p.name}
+	case "any":
+		return []string{"any element"}
+	case "sequence":
+		if len(p.children) > 0 {
+			return firstNames(p.children[0])
+		}
+	case "choice":
+		var names []string
+		for _, c := range p.children {
+			names = append(names, firstNames(c)...)
+		}
+		return names
+	}
+	return nil
+}
+
+var (
+	xsdDecimal  = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+	xsdDate     = regexp.MustCompile(`^([0-9]{4}-[0-9]{2}-[0-9]{2})(Z|[+-][0-9]{2}:[0-9]{2})?$`)
+	xsdDateTime = regexp.MustCompile(`^([0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2})(\.[0-9]+)?(Z|[+-][0-9]{2}:[0-9]{2})?$`)
+)
+
+// value checks a value against a simple type, returns the violation or the empty string
+func (v *schemaValidator) value(value string, typ string) string {
+	var restrictions []*xsdSimpleType
+	for {
+		t := v.s.simple[typ]
+		if t == nil {
+			break
+		}
+		restrictions = append(restrictions, t)
+		typ = t.base
+	}
+	if typ != "xs:string" {
+		value = strings.TrimSpace(value)
+	}
+	switch typ {
+	case "xs:string":
+	case "xs:decimal":
+		if !xsdDecimal.MatchString(value) {
+			return strconv.Quote(value) + " is not a decimal"
+		}
+	case "xs:boolean":
+		switch value {
+		case "true", "false", "1", "0":
+		default:
+			return strconv.Quote(value) + " is not a boolean"
+		}
+	case "xs:date":
+		m := xsdDate.FindStringSubmatch(value)
+		if m == nil {
+			return strconv.Quote(value) + " is not a date"
+		}
+		if _, err := time.Parse("2006-01-02", m[1]); err != nil {
+			return strconv.Quote(value) + " is not a date"
+		}
+	case "xs:dateTime":
+		m := xsdDateTime.FindStringSubmatch(value)
+		if m == nil {
+			return strconv.Quote(value) + " is not a date time"
+		}
+		if _, err := time.Parse("2006-01-02T15:04:05", m[1]); err != nil {
+			return strconv.Quote(value) + " is not a date time"
+		}
+	default:
+		return "unknown type " + typ
+	}
+	for _, t := range restrictions {
+		if msg := t.check(value); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+// check returns the facet of the type value violates, the empty string if there is none
+func (t *xsdSimpleType) check(value string) string {
+	if len(t.enumeration) > 0 {
+		found := false
+		for _, e := range t.enumeration {
+			found = found || e == value
+		}
+		if !found {
+			if len(t.enumeration) > 10 {
+				return strconv.Quote(value) + " is not an allowed code"
+			}
+			return strconv.Quote(value) + " is not one of " + strings.Join(t.enumeration, ", ")
+		}
+	}
+	if len(t.patterns) > 0 {
+		found := false
+		for _, p := range t.patterns {
+			found = found || p.MatchString(value)
+		}
+		if !found {
+			return strconv.Quote(value) + " does not match " + strings.TrimSuffix(strings.TrimPrefix(t.patterns[0].String(), "^(?:"), ")$")
+		}
+	}
+	length := utf8.RuneCountInString(value)
+	if t.minLength != nil && length < *t.minLength {
+		return strconv.Quote(value) + " is shorter than " + strconv.Itoa(*t.minLength) + " characters"
+	}
+	if t.maxLength != nil && length > *t.maxLength {
+		return strconv.Quote(value) + " is longer than " + strconv.Itoa(*t.maxLength) + " characters"
+	}
+	if t.totalDigits != nil || t.fractionDigits != nil {
+		total, fraction := decimalDigits(value)
+		if t.totalDigits != nil && total > *t.totalDigits {
+			return strconv.Quote(value) + " has more than " + strconv.Itoa(*t.totalDigits) + " digits"
+		}
+		if t.fractionDigits != nil && fraction > *t.fractionDigits {
+			return strconv.Quote(value) + " has more than " + strconv.Itoa(*t.fractionDigits) + " fraction digits"
+		}
+	}
+	if t.minInclusive != nil || t.maxInclusive != nil {
+		r, ok := new(big.Rat).SetString(value)
+		if !ok {
+			return strconv.Quote(value) + " is not a number"
+		}
+		if t.minInclusive != nil && r.Cmp(t.minInclusive) < 0 {
+			return strconv.Quote(value) + " is less than " + t.minInclusive.FloatString(2)
+		}
+		if t.maxInclusive != nil && r.Cmp(t.maxInclusive) > 0 {
+			return strconv.Quote(value) + " is more than " + t.maxInclusive.FloatString(2)
+		}
+	}
+	return ""
+}
+
+// decimalDigits returns the number of significant digits of a decimal and of its fraction
+func decimalDigits(value string) (int, int) {
+	value = strings.TrimLeft(value, "+-")
+	integer, fraction := value, ""
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		integer, fraction = value[:i], value[i+1:]
+	}
+	integer = strings.TrimLeft(integer, "0")
+	fraction = strings.TrimRight(fraction, "0")
+	return len(integer) + len(fraction), len(fraction)
+}
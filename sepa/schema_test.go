@@ -0,0 +1,201 @@
+package sepa
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// schemaErrors validates a document, failing the test unless it returns SchemaErrors
+func schemaErrors(t *testing.T, doc string) SchemaErrors {
+	err := ValidateXML(strings.NewReader(doc))
+	errs, ok := err.(SchemaErrors)
+	if !ok {
+		t.Fatal("Expected ValidateXML return SchemaErrors", "got", err)
+	}
+	return errs
+}
+
+// lineOf returns the line of the first occurrence of s in doc
+func lineOf(doc string, s string) int {
+	return strings.Count(doc[:strings.Index(doc, s)], "\n") + 1
+}
+
+func TestValidateCreditTransfer(t *testing.T) {
+	doc := splitTestTransfer(t, []string{"10", "20"}, []string{"30"})
+	err := doc.AddTransfer(Transfer{ID: "F4", Amount: eur("40"), Currency: "EUR",
+		Creditor: Party{Name: "Creditor", Address: PostalAddress{Country: "DE", AddressLines: []string{"Street 1", "12345 City"}},
+			ID: PartyID{Organisation: &OrganisationID{Other: []OtherID{{ID: "DE79ZZZ00000000001", SchemeCode: "CUST"}}}}},
+		CreditorAccount: Account{IBAN: "GB29NWBK60161331926819"}, CreditorAgent: Agent{BIC: "NWBKGB2L"}, Description: "Invoice F4"})
+	if err != nil {
+		t.Fatal("Expected AddTransfer return nil", "got", err)
+	}
+	if err := doc.Validate(); err != nil {
+		t.Fatal("Expected Validate return nil", "got", err)
+	}
+	b, err := doc.PrettySerialize()
+	if err != nil {
+		t.Fatal("Expected PrettySerialize return nil", "got", err)
+	}
+	str := string(b)
+
+	// pattern
+	bad := strings.Replace(str, "<IBAN>GB29NWBK60161331926819</IBAN>", "<IBAN>gb29 NWBK</IBAN>", 1)
+	errs := schemaErrors(t, bad)
+	if len(errs) != 1 || errs[0].Line != lineOf(bad, "gb29") ||
+		errs[0].Path != "/Document/CstmrCdtTrfInitn/PmtInf[1]/CdtTrfTxInf[1]/CdtrAcct/Id/IBAN" ||
+		!strings.HasPrefix(errs[0].Message, `"gb29 NWBK" does not match`) {
+		t.Error("Expected an error for the IBAN of F1", "got", errs)
+	}
+
+	// missing element and element order
+	bad = strings.Replace(str, "<NbOfTxs>4</NbOfTxs>", "", 1)
+	errs = schemaErrors(t, bad)
+	if len(errs) != 1 || errs[0].Path != "/Document/CstmrCdtTrfInitn/GrpHdr/CtrlSum" || errs[0].Message != "unexpected element, expected NbOfTxs" {
+		t.Error("Expected an error for the missing NbOfTxs", "got", errs)
+	}
+	if err := ValidateXML(strings.NewReader(strings.Replace(str, "<Nm>Creditor</Nm>", "", 1))); err != nil {
+		t.Error("Expected no error without the optional Nm", "got", err)
+	}
+
+	// text instead of an identification
+	bad = strings.Replace(str, "<OrgId>", "<OrgId>DE79ZZZ00000000001", 1)
+	errs = schemaErrors(t, bad)
+	if len(errs) != 1 || errs[0].Path != "/Document/CstmrCdtTrfInitn/PmtInf[2]/CdtTrfTxInf[2]/Cdtr/Id/OrgId" ||
+		errs[0].Message != `unexpected text "DE79ZZZ00000000001"` {
+		t.Error("Expected an error for the text of OrgId", "got", errs)
+	}
+
+	// cardinality
+	bad = strings.Replace(str, "<AdrLine>12345 City</AdrLine>", "<AdrLine>12345 City</AdrLine>"+strings.Repeat("<AdrLine>more</AdrLine>", 6), 1)
+	errs = schemaErrors(t, bad)
+	if len(errs) != 1 || errs[0].Path != "/Document/CstmrCdtTrfInitn/PmtInf[2]/CdtTrfTxInf[2]/Cdtr/PstlAdr/AdrLine[8]" ||
+		errs[0].Message != "more than 7 AdrLine" {
+		t.Error("Expected an error for the eighth AdrLine", "got", errs)
+	}
+
+	// fraction digits and enumeration
+	bad = strings.Replace(str, ">10.00<", ">10.000001<", 1)
+	bad = strings.Replace(bad, "<ChrgBr>SLEV</ChrgBr>", "<ChrgBr>ANY</ChrgBr>", 1)
+	errs = schemaErrors(t, bad)
+	if len(errs) != 2 || errs[0].Line != lineOf(bad, "ANY") || errs[1].Line != lineOf(bad, "10.000001") ||
+		!strings.HasPrefix(errs[0].Message, `"ANY" is not one of`) || errs[1].Message != `"10.000001" has more than 5 fraction digits` {
+		t.Error("Expected errors for the charge bearer and the amount of F1", "got", errs)
+	}
+}
+func TestValidateDirectDebit(t *testing.T) {
+	doc, err := NewDirectDebit(DirectDebitConfig{MsgID: "MSGID", PaymentInfoID: "PMT", CreationDate: at("2017-06-07T14:39:33"),
+		CollectionDate: day("2017-06-11"), Initiator: testEmitter, CreditorAccount: testEmitterAccount, CreditorSchemeID: "DE98ZZZ09999999999"})
+	if err != nil {
+		t.Fatal("Expected NewDirectDebit return nil", "got", err)
+	}
+	for i, sequenceType := range []string{SequenceFirst, SequenceRecurring} {
+		id := "E2E-" + string(rune('1'+i))
+		if err := doc.AddTransaction(id, eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "", "Invoice", "MNDT-"+id, "2017-01-01", sequenceType, ""); err != nil {
+			t.Fatal("Expected AddTransaction return nil", "got", err)
+		}
+	}
+	if err := doc.Validate(); err != nil {
+		t.Fatal("Expected Validate return nil", "got", err)
+	}
+	b, err := doc.Serialize()
+	if err != nil {
+		t.Fatal("Expected Serialize return nil", "got", err)
+	}
+	if bytes.Contains(b, []byte("<Othr></Othr>")) {
+		t.Error("Expected no empty creditor scheme ID in the transactions", "got", string(b))
+	}
+	bad := strings.Replace(string(b), "<SeqTp>RCUR</SeqTp>", "<SeqTp>ONCE</SeqTp>", 1)
+	bad = strings.Replace(bad, "<ReqdColltnDt>2017-06-11</ReqdColltnDt>", "<ReqdColltnDt>2017-06-31</ReqdColltnDt>", 1)
+	errs := schemaErrors(t, bad)
+	if len(errs) != 2 || errs[0].Message != `"2017-06-31" is not a date` || errs[0].Line != 2 ||
+		errs[1].Path != "/Document/CstmrDrctDbtInitn/PmtInf[2]/PmtTpInf/SeqTp" {
+		t.Error("Expected errors for the sequence type and the collection date", "got", errs)
+	}
+
+	if err := ValidateXML(strings.NewReader("<Document>")); err == nil {
+		t.Error("Expected ValidateXML return an error for a truncated document")
+	}
+}
+func TestValidateWithoutAddress(t *testing.T) {
+	emitter := Party{Name: "Emitter Name"}
+	ct, err := NewCreditTransfer(CreditTransferConfig{MsgID: "MSGID", PaymentInfoID: "PMT", CreationDate: at("2017-06-07T14:39:33"),
+		ExecutionDate: day("2017-06-11"), Initiator: emitter, DebtorAccount: testEmitterAccount, DebtorAgent: testEmitterAgent})
+	if err != nil {
+		t.Fatal("Expected NewCreditTransfer return nil", "got", err)
+	}
+	if err := ct.AddTransaction("E2E-1", eur("10"), "EUR", "Creditor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice"); err != nil {
+		t.Fatal("Expected AddTransaction return nil", "got", err)
+	}
+	if err := ct.Validate(); err != nil {
+		t.Error("Expected Validate return nil", "got", err)
+	}
+	dd, err := NewDirectDebit(DirectDebitConfig{Version: Pain008DKV02, MsgID: "MSGID", PaymentInfoID: "PMT", CreationDate: at("2017-06-07T14:39:33"),
+		CollectionDate: day("2017-06-11"), Initiator: emitter, CreditorAccount: testEmitterAccount, CreditorAgent: testEmitterAgent,
+		CreditorSchemeID: "DE98ZZZ09999999999"})
+	if err != nil {
+		t.Fatal("Expected NewDirectDebit return nil", "got", err)
+	}
+	if err := dd.AddTransaction("E2E-1", eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-1", "2017-01-01", SequenceFirst, ""); err != nil {
+		t.Fatal("Expected AddTransaction return nil", "got", err)
+	}
+	if err := dd.Validate(); err != nil {
+		t.Error("Expected Validate return nil", "got", err)
+	}
+	for _, doc := range []interface{ Serialize() ([]byte, error) }{ct, dd} {
+		if b, _ := doc.Serialize(); bytes.Contains(b, []byte("PstlAdr")) {
+			t.Error("Expected no postal address", "got", string(b))
+		}
+	}
+}
+func TestValidateVersions(t *testing.T) {
+	for _, version := range []string{Pain001V03, Pain001V09, Pain008DKV02, Pain008V02, Pain008V08} {
+		t.Run(version, func(t *testing.T) {
+			var doc interface {
+				Validate() error
+				PrettySerialize() ([]byte, error)
+			}
+			if strings.HasPrefix(version, "pain.001") {
+				ct := splitTestTransfer(t, []string{"10", "20"}, []string{"30"})
+				if err := ct.SetVersion(version); err != nil {
+					t.Fatal("Expected SetVersion return nil", "got", err)
+				}
+				doc = ct
+			} else {
+				dd, err := NewDirectDebit(DirectDebitConfig{Version: version, MsgID: "MSGID", PaymentInfoID: "PMT", CreationDate: at("2017-06-07T14:39:33"),
+					CollectionDate: day("2017-06-11"), Initiator: testEmitter, CreditorAccount: testEmitterAccount, CreditorAgent: testEmitterAgent,
+					CreditorSchemeID: "DE98ZZZ09999999999"})
+				if err != nil {
+					t.Fatal("Expected NewDirectDebit return nil", "got", err)
+				}
+				for i, sequenceType := range []string{SequenceFirst, SequenceRecurring} {
+					id := "E2E-" + string(rune('1'+i))
+					if err := dd.AddTransaction(id, eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-"+id, "2017-01-01", sequenceType, ""); err != nil {
+						t.Fatal("Expected AddTransaction return nil", "got", err)
+					}
+				}
+				doc = dd
+			}
+
+			// valid
+			if err := doc.Validate(); err != nil {
+				t.Fatal("Expected Validate return nil", "got", err)
+			}
+
+			// invalid
+			b, err := doc.PrettySerialize()
+			if err != nil {
+				t.Fatal("Expected PrettySerialize return nil", "got", err)
+			}
+			bad := strings.Replace(string(b), "<IBAN>GB29NWBK60161331926819</IBAN>", "<IBAN>gb29 NWBK</IBAN>", 1)
+			errs := schemaErrors(t, bad)
+			if len(errs) != 1 || errs[0].Line != lineOf(bad, "gb29") || !strings.HasSuffix(errs[0].Path, "/IBAN") {
+				t.Error("Expected an error for the IBAN", "got", errs)
+			}
+		})
+	}
+
+	if _, err := loadSchema("pain.001.001.04"); err == nil || !strings.HasPrefix(err.Error(), "no embedded schema for pain.001.001.04") {
+		t.Error("Expected loadSchema return an error for a version without XSD", "got", err)
+	}
+}
@@ -0,0 +1,340 @@
+package sepa
+
+import (
+	"errors"
+	"strconv"
+)
+
+// SplitLimits caps the documents returned by Split, a zero field sets no limit
+type SplitLimits struct {
+	// MaxTransactions caps the number of transactions of a document
+	MaxTransactions int
+	// MaxAmount caps the control sum of a document
+	MaxAmount Amount
+	// MaxPaymentInfoAmount caps the control sum of a payment information group
+	MaxPaymentInfoAmount Amount
+	// MaxSize caps the size in bytes of a document written by Serialize
+	MaxSize int
+}
+
+// SplitEntry tells where Split put a transaction
+type SplitEntry struct {
+	EndToEndID string
+	// FromPaymentInfoID is the payment information group of the transaction in the split document
+	FromPaymentInfoID string
+	// Document is the index of the document in the result of Split
+	Document      int
+	MsgID         string
+	PaymentInfoID string
+}
+
+// splitSlack is the room left per document and group for the transaction numbers and control sums,
+// measured with no transactions
+const splitSlack = 48
+
+// splitItem is a transaction as seen by planSplit
+type splitItem struct {
+	id     string
+	amount Amount
+	size   int
+}
+
+// splitSlot is the document and the part of its group planSplit puts a transaction in, both counted from 0
+type splitSlot struct {
+	doc  int
+	part int
+}
+
+// planSplit assigns the transactions of every group to documents and parts of the group, filling each document in
+// turn. base is the size of a document without groups, overheads the size of every group without transactions.
+// A group is split into parts when it outgrows MaxPaymentInfoAmount or its document. A document without transactions
+// has nothing to plan and returns an error.
+func planSplit(limits SplitLimits, base int, overheads []int, groups [][]splitItem) ([][]splitSlot, error) {
+	empty := true
+	for _, items := range groups {
+		empty = empty && len(items) == 0
+	}
+	if empty {
+		return nil, errors.New("no transaction to split")
+	}
+	base += splitSlack
+	slots := make([][]splitSlot, len(groups))
+	doc := -1
+	var docCount, docSize int
+	var docAmount Amount
+	for g, items := range groups {
+		overhead := overheads[g] + splitSlack
+		part := -1
+		var partAmount Amount
+		for _, item := range items {
+			if exceeds(item.amount, limits.MaxAmount) || exceeds(item.amount, limits.MaxPaymentInfoAmount) ||
+				(limits.MaxSize > 0 && base+overhead+item.size > limits.MaxSize) {
+				return nil, errors.New("transaction " + item.id + " alone exceeds the limits")
+			}
+			newPart := part < 0
+			nextPartAmount, err := partAmount.Add(item.amount)
+			if err != nil {
+				return nil, err
+			}
+			if exceeds(nextPartAmount, limits.MaxPaymentInfoAmount) {
+				newPart = true
+			}
+			size := item.size
+			if newPart {
+				size += overhead
+			}
+			nextDocAmount, err := docAmount.Add(item.amount)
+			if err != nil {
+				return nil, err
+			}
+			if doc < 0 || (limits.MaxTransactions > 0 && docCount+1 > limits.MaxTransactions) ||
+				exceeds(nextDocAmount, limits.MaxAmount) || (limits.MaxSize > 0 && docSize+size > limits.MaxSize) {
+				doc++
+				docCount, docSize, docAmount = 0, base, Amount{}
+				newPart = true
+				size = item.size + overhead
+			}
+			if newPart {
+				part++
+				partAmount = Amount{}
+			}
+			if partAmount, err = partAmount.Add(item.amount); err != nil {
+				return nil, err
+			}
+			if docAmount, err = docAmount.Add(item.amount); err != nil {
+				return nil, err
+			}
+			docCount++
+			docSize += size
+			slots[g] = append(slots[g], splitSlot{doc: doc, part: part})
+		}
+	}
+	return slots, nil
+}
+
+// exceeds tells whether an amount is over a limit, a zero limit sets none
+func exceeds(amount Amount, limit Amount) bool {
+	return !limit.IsZero() && amount.Cmp(limit) > 0
+}
+
+// splitID returns the ID of the part n, counted from 0, of a split message or group, the ID itself if it isn't split.
+// It returns an error if the suffix makes it longer than the schemas allow.
+func splitID(field string, id string, n int, parts int) (string, error) {
+	if parts < 2 {
+		return id, nil
+	}
+	id += "/" + strconv.Itoa(n+1)
+	return id, checkID(field, id)
+}
+
+// partCount returns the number of documents and the number of parts of every group of a plan
+func partCount(slots [][]splitSlot) (int, []int) {
+	docs := 0
+	parts := make([]int, len(slots))
+	for g, group := range slots {
+		for _, s := range group {
+			if s.doc+1 > docs {
+				docs = s.doc + 1
+			}
+			if s.part+1 > parts[g] {
+				parts[g] = s.part + 1
+			}
+		}
+	}
+	return docs, parts
+}
+
+// serializedSize returns the size of a document written by Serialize
+func serializedSize(serialize func() ([]byte, error)) (int, error) {
+	b, err := serialize()
+	return len(b), err
+}
+
+// assembleSplit builds the documents of a plan and returns the mapping of every transaction, the groups being the
+// payment info IDs and transactions measured for planSplit. newDoc starts document d with its message ID and no
+// groups, add appends transaction t of group g to document d under the payment info ID of its part, creating the group
+// when missing, and finish recounts document d and checks its size.
+func assembleSplit(msgID string, paymentInfoIDs []string, groups [][]splitItem, slots [][]splitSlot,
+	newDoc func(d int, msgID string), add func(d int, g int, t int, paymentInfoID string), finish func(d int) error) ([]SplitEntry, error) {
+	docCount, parts := partCount(slots)
+	msgIDs := make([]string, docCount)
+	for d := range msgIDs {
+		var err error
+		if msgIDs[d], err = splitID("message ID", msgID, d, docCount); err != nil {
+			return nil, err
+		}
+		newDoc(d, msgIDs[d])
+	}
+	var mapping []SplitEntry
+	for g, items := range groups {
+		for t, item := range items {
+			slot := slots[g][t]
+			id, err := splitID("payment info ID", paymentInfoIDs[g], slot.part, parts[g])
+			if err != nil {
+				return nil, err
+			}
+			add(slot.doc, g, t, id)
+			mapping = append(mapping, SplitEntry{EndToEndID: item.id, FromPaymentInfoID: paymentInfoIDs[g],
+				Document: slot.doc, MsgID: msgIDs[slot.doc], PaymentInfoID: id})
+		}
+	}
+	for d := range msgIDs {
+		if err := finish(d); err != nil {
+			return nil, err
+		}
+	}
+	return mapping, nil
+}
+
+// Split returns the transactions of the document spread over as few documents as the limits allow, in their order,
+// with the mapping of every transaction to its document. The documents get the message ID followed by "/1", "/2"...
+// when there are several, a payment information group split into parts gets its ID followed by "/1", "/2"...
+// A document without transactions returns an error. The document itself is left unchanged.
+func (doc *CreditTransfer) Split(limits SplitLimits) ([]*CreditTransfer, []SplitEntry, error) {
+	skeleton := *doc
+	skeleton.PaymentInfos = nil
+	skeleton.GroupHeaderTransactNo, skeleton.GroupHeaderCtrlSum = 0, Amount{}
+	base, err := serializedSize(skeleton.Serialize)
+	if err != nil {
+		return nil, nil, err
+	}
+	overheads := make([]int, len(doc.PaymentInfos))
+	groups := make([][]splitItem, len(doc.PaymentInfos))
+	paymentInfoIDs := make([]string, len(doc.PaymentInfos))
+	for g, p := range doc.PaymentInfos {
+		paymentInfoIDs[g] = p.PaymentInfoID
+		p.PaymentTransactions, p.PaymentInfoTransactNo, p.PaymentInfoCtrlSum = nil, 0, Amount{}
+		skeleton.PaymentInfos = []CreditPaymentInfo{p}
+		size, err := serializedSize(skeleton.Serialize)
+		if err != nil {
+			return nil, nil, err
+		}
+		overheads[g] = size - base
+		for _, t := range doc.PaymentInfos[g].PaymentTransactions {
+			skeleton.PaymentInfos[0].PaymentTransactions = []CreditTransaction{t}
+			txSize, err := serializedSize(skeleton.Serialize)
+			if err != nil {
+				return nil, nil, err
+			}
+			groups[g] = append(groups[g], splitItem{id: t.TransactIDe2e, amount: t.TransactAmount.Amount, size: txSize - size})
+		}
+	}
+	slots, err := planSplit(limits, base, overheads, groups)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var docs []*CreditTransfer
+	mapping, err := assembleSplit(doc.GroupHeaderMsgID, paymentInfoIDs, groups, slots, func(d int, msgID string) {
+		out := *doc
+		out.GroupHeaderMsgID = msgID
+		out.PaymentInfos = nil
+		docs = append(docs, &out)
+	}, func(d int, g int, t int, paymentInfoID string) {
+		out, p := docs[d], doc.PaymentInfos[g]
+		pmtInf := out.PaymentInfo(paymentInfoID)
+		if pmtInf == nil {
+			part := p
+			part.PaymentInfoID = paymentInfoID
+			part.PaymentEmitterPostalAddress = p.PaymentEmitterPostalAddress.clone()
+			part.PaymentTransactions = nil
+			out.PaymentInfos = append(out.PaymentInfos, part)
+			pmtInf = &out.PaymentInfos[len(out.PaymentInfos)-1]
+		}
+		pmtInf.PaymentTransactions = append(pmtInf.PaymentTransactions, p.PaymentTransactions[t])
+	}, func(d int) error {
+		if err := docs[d].Recalculate(); err != nil {
+			return err
+		}
+		return checkSplitSize(limits, docs[d].Serialize)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return docs, mapping, nil
+}
+
+// Split returns the transactions of the document spread over as few documents as the limits allow, in their order,
+// with the mapping of every transaction to its document. The documents get the message ID followed by "/1", "/2"...
+// when there are several, a payment information group split into parts gets its ID followed by "/1", "/2"...
+// A document without transactions returns an error. The document itself is left unchanged, transactions added to a
+// part start from its creditor information.
+func (doc *DirectDebit) Split(limits SplitLimits) ([]*DirectDebit, []SplitEntry, error) {
+	skeleton := *doc
+	skeleton.PaymentInfos = nil
+	skeleton.GroupHeaderTransactNo, skeleton.GroupHeaderCtrlSum = 0, Amount{}
+	base, err := serializedSize(skeleton.Serialize)
+	if err != nil {
+		return nil, nil, err
+	}
+	overheads := make([]int, len(doc.PaymentInfos))
+	groups := make([][]splitItem, len(doc.PaymentInfos))
+	paymentInfoIDs := make([]string, len(doc.PaymentInfos))
+	for g, p := range doc.PaymentInfos {
+		paymentInfoIDs[g] = p.PaymentInfoID
+		p.PaymentTransactions, p.PaymentInfoTransactNo, p.PaymentInfoCtrlSum = nil, 0, Amount{}
+		skeleton.PaymentInfos = []DebitPaymentInfo{p}
+		size, err := serializedSize(skeleton.Serialize)
+		if err != nil {
+			return nil, nil, err
+		}
+		overheads[g] = size - base
+		for _, t := range doc.PaymentInfos[g].PaymentTransactions {
+			skeleton.PaymentInfos[0].PaymentTransactions = []DebitTransaction{t}
+			txSize, err := serializedSize(skeleton.Serialize)
+			if err != nil {
+				return nil, nil, err
+			}
+			groups[g] = append(groups[g], splitItem{id: t.TransactIDe2e, amount: t.TransactAmount.Amount, size: txSize - size})
+		}
+	}
+	slots, err := planSplit(limits, base, overheads, groups)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var docs []*DirectDebit
+	mapping, err := assembleSplit(doc.GroupHeaderMsgID, paymentInfoIDs, groups, slots, func(d int, msgID string) {
+		out := *doc
+		out.GroupHeaderMsgID = msgID
+		out.PaymentInfos = nil
+		docs = append(docs, &out)
+	}, func(d int, g int, t int, paymentInfoID string) {
+		out, p := docs[d], doc.PaymentInfos[g]
+		pmtInf := out.PaymentInfo(paymentInfoID)
+		if pmtInf == nil {
+			part := p
+			part.PaymentInfoID = paymentInfoID
+			part.PaymentEmitterPostalAddress = p.PaymentEmitterPostalAddress.clone()
+			part.PaymentTransactions = nil
+			out.PaymentInfos = append(out.PaymentInfos, part)
+			pmtInf = &out.PaymentInfos[len(out.PaymentInfos)-1]
+		}
+		pmtInf.PaymentTransactions = append(pmtInf.PaymentTransactions, p.PaymentTransactions[t])
+	}, func(d int) error {
+		if err := docs[d].Recalculate(); err != nil {
+			return err
+		}
+		return checkSplitSize(limits, docs[d].Serialize)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return docs, mapping, nil
+}
+
+// checkSplitSize returns an error if a split document is over MaxSize after all,
+// its transaction numbers and control sums took more room than planSplit left for them
+func checkSplitSize(limits SplitLimits, serialize func() ([]byte, error)) error {
+	if limits.MaxSize == 0 {
+		return nil
+	}
+	size, err := serializedSize(serialize)
+	if err != nil {
+		return err
+	}
+	if size > limits.MaxSize {
+		return errors.New("split document of " + strconv.Itoa(size) + " bytes over the size limit")
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package sepa
+
+import (
+	"strings"
+	"testing"
+)
+
+// splitTestTransfer returns a credit transfer with the amounts in two groups, PMT-1 and PMT-2
+func splitTestTransfer(t *testing.T, first []string, second []string) *CreditTransfer {
+	doc, err := NewCreditTransfer(CreditTransferConfig{MsgID: "MSGID", PaymentInfoID: "PMT-1", CreationDate: at("2017-05-01T22:45:03"),
+		ExecutionDate: day("2017-05-03"), Initiator: testEmitter, DebtorAccount: testEmitterAccount})
+	if err != nil {
+		t.Fatal("Expected NewCreditTransfer return nil", "got", err)
+	}
+	n := 0
+	for g, amounts := range [][]string{first, second} {
+		if g == 1 {
+			if err := doc.AddPaymentInfo("PMT-2", "2017-05-04", "Emitter Name", "AT611904300234573201", "", "DE", "some street", "some city"); err != nil {
+				t.Fatal("Expected AddPaymentInfo return nil", "got", err)
+			}
+		}
+		for _, amount := range amounts {
+			n++
+			id := "F" + string(rune('0'+n))
+			if err := doc.AddTransaction(id, eur(amount), "EUR", "Creditor", "GB29NWBK60161331926819", "", "Invoice "+id); err != nil {
+				t.Fatal("Expected AddTransaction return nil", "got", err)
+			}
+		}
+	}
+	return doc
+}
+
+func TestSplitCreditTransfer(t *testing.T) {
+	doc := splitTestTransfer(t, []string{"10", "20", "30"}, []string{"40", "50"})
+
+	// by transaction count
+	docs, mapping, err := doc.Split(SplitLimits{MaxTransactions: 2})
+	if err != nil {
+		t.Fatal("Expected Split return nil", "got", err)
+	}
+	if len(docs) != 3 {
+		t.Fatal("Expected 3 documents", "got", len(docs))
+	}
+	expected := []SplitEntry{
+		{"F1", "PMT-1", 0, "MSGID/1", "PMT-1/1"},
+		{"F2", "PMT-1", 0, "MSGID/1", "PMT-1/1"},
+		{"F3", "PMT-1", 1, "MSGID/2", "PMT-1/2"},
+		{"F4", "PMT-2", 1, "MSGID/2", "PMT-2/1"},
+		{"F5", "PMT-2", 2, "MSGID/3", "PMT-2/2"},
+	}
+	if len(mapping) != len(expected) {
+		t.Fatal("Expected", expected, "got", mapping)
+	}
+	for i, e := range expected {
+		if mapping[i] != e {
+			t.Error("Expected", e, "got", mapping[i])
+		}
+	}
+	totals := []struct {
+		transactNo int
+		ctrlSum    Amount
+		groups     int
+	}{{2, eur("30"), 1}, {2, eur("70"), 2}, {1, eur("50"), 1}}
+	for i, e := range totals {
+		d := docs[i]
+		if d.GroupHeaderTransactNo != e.transactNo || d.GroupHeaderCtrlSum != e.ctrlSum || len(d.PaymentInfos) != e.groups {
+			t.Error("Expected", e, "got", d.GroupHeaderTransactNo, d.GroupHeaderCtrlSum, len(d.PaymentInfos))
+		}
+		if d.PaymentInfos[0].PaymentInfoTransactNo != len(d.PaymentInfos[0].PaymentTransactions) {
+			t.Error("Expected group totals of", d.GroupHeaderMsgID, "recalculated")
+		}
+	}
+	if doc.GroupHeaderTransactNo != 5 || len(doc.PaymentInfos) != 2 {
+		t.Error("Expected the split document unchanged", "got", doc.GroupHeaderTransactNo, len(doc.PaymentInfos))
+	}
+
+	// by amount per group, in a single document
+	docs, mapping, err = doc.Split(SplitLimits{MaxPaymentInfoAmount: eur("50")})
+	if err != nil {
+		t.Fatal("Expected Split return nil", "got", err)
+	}
+	if len(docs) != 1 || docs[0].GroupHeaderMsgID != "MSGID" || len(docs[0].PaymentInfos) != 4 {
+		t.Fatal("Expected one document MSGID with 4 groups", "got", len(docs))
+	}
+	for i, id := range []string{"PMT-1/1", "PMT-1/2", "PMT-2/1", "PMT-2/2"} {
+		if p := docs[0].PaymentInfos[i]; p.PaymentInfoID != id || exceeds(p.PaymentInfoCtrlSum, eur("50")) {
+			t.Error("Expected group", id, "up to 50.00", "got", p.PaymentInfoID, p.PaymentInfoCtrlSum)
+		}
+	}
+	if mapping[2].PaymentInfoID != "PMT-1/2" {
+		t.Error("Expected F3 in PMT-1/2", "got", mapping[2])
+	}
+
+	// by amount per document
+	docs, _, err = doc.Split(SplitLimits{MaxAmount: eur("60")})
+	if err != nil {
+		t.Fatal("Expected Split return nil", "got", err)
+	}
+	if len(docs) != 3 {
+		t.Error("Expected 3 documents", "got", len(docs))
+	}
+	for _, d := range docs {
+		if exceeds(d.GroupHeaderCtrlSum, eur("60")) {
+			t.Error("Expected documents up to 60.00", "got", d.GroupHeaderCtrlSum)
+		}
+	}
+
+	// by size
+	str, _ := doc.Serialize()
+	limit := len(str) * 2 / 3
+	docs, _, err = doc.Split(SplitLimits{MaxSize: limit})
+	if err != nil {
+		t.Fatal("Expected Split return nil", "got", err)
+	}
+	if len(docs) < 2 {
+		t.Error("Expected several documents", "got", len(docs))
+	}
+	n := 0
+	for _, d := range docs {
+		str, _ := d.Serialize()
+		if len(str) > limit {
+			t.Error("Expected documents up to", limit, "bytes", "got", len(str))
+		}
+		if !strings.Contains(string(str), "<MsgId>"+d.GroupHeaderMsgID+"</MsgId>") {
+			t.Error("Expected", d.GroupHeaderMsgID, "in", string(str))
+		}
+		n += d.GroupHeaderTransactNo
+	}
+	if n != 5 {
+		t.Error("Expected 5 transactions", "got", n)
+	}
+
+	// nothing to split
+	docs, _, err = doc.Split(SplitLimits{})
+	if err != nil || len(docs) != 1 || docs[0].GroupHeaderCtrlSum != doc.GroupHeaderCtrlSum || docs[0].PaymentInfos[1].PaymentInfoID != "PMT-2" {
+		t.Error("Expected the document as it is", "got", docs, err)
+	}
+
+	// no transaction
+	if docs, _, err := splitTestTransfer(t, nil, nil).Split(SplitLimits{}); err == nil || err.Error() != "no transaction to split" {
+		t.Error("Expected Split return an error for a document without transactions", "got", docs, err)
+	}
+
+	// a transaction over the limits alone
+	if _, _, err := doc.Split(SplitLimits{MaxPaymentInfoAmount: eur("45")}); err == nil {
+		t.Error("Expected Split return an error for a transaction over the amount limit")
+	}
+	if _, _, err := doc.Split(SplitLimits{MaxSize: 500}); err == nil {
+		t.Error("Expected Split return an error for a transaction over the size limit")
+	}
+
+	// IDs too long for a suffix
+	long := *doc
+	long.GroupHeaderMsgID = strings.Repeat("M", 34)
+	if _, _, err := long.Split(SplitLimits{MaxTransactions: 2}); err == nil || !strings.Contains(err.Error(), "message ID") {
+		t.Error("Expected Split return an error for a message ID too long", "got", err)
+	}
+	long = *doc
+	long.PaymentInfos = append([]CreditPaymentInfo(nil), doc.PaymentInfos...)
+	long.PaymentInfos[0].PaymentInfoID = strings.Repeat("P", 34)
+	if _, _, err := long.Split(SplitLimits{MaxPaymentInfoAmount: eur("50")}); err == nil || !strings.Contains(err.Error(), "payment info ID") {
+		t.Error("Expected Split return an error for a payment info ID too long", "got", err)
+	}
+}
+func TestSplitDirectDebit(t *testing.T) {
+	doc, err := NewDirectDebit(DirectDebitConfig{Version: Pain008V08, MsgID: "MSGID", PaymentInfoID: "PMT", CreationDate: at("2017-06-07T14:39:33"),
+		CollectionDate: day("2017-06-11"), Initiator: testEmitter, CreditorAccount: testEmitterAccount, CreditorSchemeID: "DE98ZZZ09999999999"})
+	if err != nil {
+		t.Fatal("Expected NewDirectDebit return nil", "got", err)
+	}
+	for _, id := range []string{"E2E-1", "E2E-2", "E2E-3"} {
+		if err := doc.AddTransaction(id, eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "", "Invoice", "MNDT-"+id, "2017-01-01", SequenceRecurring, ""); err != nil {
+			t.Fatal("Expected AddTransaction return nil", "got", err)
+		}
+	}
+	docs, mapping, err := doc.Split(SplitLimits{MaxTransactions: 2})
+	if err != nil {
+		t.Fatal("Expected Split return nil", "got", err)
+	}
+	if len(docs) != 2 || docs[1].GroupHeaderMsgID != "MSGID/2" || docs[1].PaymentInfos[0].PaymentInfoID != "PMT/2" || docs[1].Version() != Pain008V08 {
+		t.Fatal("Expected 2 pain.008.001.08 documents", "got", len(docs))
+	}
+	if mapping[2] != (SplitEntry{"E2E-3", "PMT", 1, "MSGID/2", "PMT/2"}) {
+		t.Error("Expected E2E-3 in MSGID/2", "got", mapping[2])
+	}
+	if docs[0].GroupHeaderCtrlSum != eur("20") || docs[1].GroupHeaderCtrlSum != eur("10") {
+		t.Error("Expected control sums 20.00 and 10.00", "got", docs[0].GroupHeaderCtrlSum, docs[1].GroupHeaderCtrlSum)
+	}
+
+	// a split document takes new transactions
+	if err := docs[1].AddTransaction("E2E-4", eur("5"), "EUR", "Debtor", "GB29NWBK60161331926819", "", "Invoice", "MNDT-4", "2017-01-01", SequenceFirst, ""); err != nil {
+		t.Fatal("Expected AddTransaction return nil", "got", err)
+	}
+	if p := docs[1].PaymentInfos[1]; p.PaymentEmitterID != "DE98ZZZ09999999999" || docs[1].GroupHeaderTransactNo != 2 {
+		t.Error("Expected a FRST group with the creditor of the split document", "got", p)
+	}
+}
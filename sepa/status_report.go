@@ -0,0 +1,222 @@
+package sepa
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// Status codes of a payment status report
+const (
+	StatusAccepted                 = "ACCP" // accepted customer profile
+	StatusAcceptedTechnical        = "ACTC" // accepted technical validation
+	StatusAcceptedSettlementInProc = "ACSP" // accepted settlement in process
+	StatusAcceptedSettlementDone   = "ACSC" // accepted settlement completed
+	StatusAcceptedWithChange       = "ACWC" // accepted with change
+	StatusPartiallyAccepted        = "PART" // some transactions accepted, some rejected
+	StatusPending                  = "PDNG"
+	StatusRejected                 = "RJCT"
+)
+
+// PaymentStatusReport is a pain.002 customer payment status report answering a pain.001 or pain.008 document
+type PaymentStatusReport struct {
+	Version           string
+	MsgID             string
+	CreationDate      string
+	OriginalMsgID     string
+	OriginalMsgNameID string
+	GroupStatus       string
+	GroupReasons      []StatusReason
+	PaymentInfos      []PaymentInfoStatus
+}
+
+// PaymentInfoStatus is the status of a payment information group and of its transactions
+type PaymentInfoStatus struct {
+	OriginalPaymentInfoID string
+	Status                string
+	Reasons               []StatusReason
+	Transactions          []TransactionStatus
+}
+
+// TransactionStatus is the status of a single transaction
+type TransactionStatus struct {
+	StatusID              string
+	OriginalInstructionID string
+	OriginalEndToEndID    string
+	Status                string
+	Reasons               []StatusReason
+}
+
+// StatusReason is a reason (ISO code such as AC01, or proprietary) given for a status
+type StatusReason struct {
+	Code           string   `xml:"Rsn>Cd"`
+	Proprietary    string   `xml:"Rsn>Prtry"`
+	AdditionalInfo []string `xml:"AddtlInf"`
+}
+
+// Rejected tells whether the transaction was rejected, false for a nil status
+func (s *TransactionStatus) Rejected() bool {
+	return s != nil && s.Status == StatusRejected
+}
+
+// pain002 is the layout of a pain.002 document, shared by all supported versions
+type pain002 struct {
+	XMLName           xml.Name             `xml:"Document"`
+	MsgID             string               `xml:"CstmrPmtStsRpt>GrpHdr>MsgId"`
+	CreationDate      string               `xml:"CstmrPmtStsRpt>GrpHdr>CreDtTm"`
+	OriginalMsgID     string               `xml:"CstmrPmtStsRpt>OrgnlGrpInfAndSts>OrgnlMsgId"`
+	OriginalMsgNameID string               `xml:"CstmrPmtStsRpt>OrgnlGrpInfAndSts>OrgnlMsgNmId"`
+	GroupStatus       string               `xml:"CstmrPmtStsRpt>OrgnlGrpInfAndSts>GrpSts"`
+	GroupReasons      []StatusReason       `xml:"CstmrPmtStsRpt>OrgnlGrpInfAndSts>StsRsnInf"`
+	PaymentInfos      []pain002PaymentInfo `xml:"CstmrPmtStsRpt>OrgnlPmtInfAndSts"`
+}
+
+// pain002PaymentInfo is the layout of an original payment information and status (OrgnlPmtInfAndSts)
+type pain002PaymentInfo struct {
+	OriginalPaymentInfoID string               `xml:"OrgnlPmtInfId"`
+	Status                string               `xml:"PmtInfSts"`
+	Reasons               []StatusReason       `xml:"StsRsnInf"`
+	Transactions          []pain002Transaction `xml:"TxInfAndSts"`
+}
+
+// pain002Transaction is the layout of a transaction information and status (TxInfAndSts)
+type pain002Transaction struct {
+	StatusID              string         `xml:"StsId"`
+	OriginalInstructionID string         `xml:"OrgnlInstrId"`
+	OriginalEndToEndID    string         `xml:"OrgnlEndToEndId"`
+	Status                string         `xml:"TxSts"`
+	Reasons               []StatusReason `xml:"StsRsnInf"`
+}
+
+// ParsePaymentStatusReport reads a pain.002 document in any supported schema version
+func ParsePaymentStatusReport(r io.Reader) (*PaymentStatusReport, error) {
+	d := xml.NewDecoder(r)
+	start, err := rootElement(d)
+	if err != nil {
+		return nil, err
+	}
+	version := versionOf(start.Name.Space)
+	if err := checkVersion(version, Pain002DKV03, Pain002V03, Pain002V10); err != nil {
+		return nil, err
+	}
+	layout := &pain002{}
+	if err := d.DecodeElement(layout, &start); err != nil {
+		return nil, err
+	}
+
+	report := &PaymentStatusReport{
+		Version:           version,
+		MsgID:             layout.MsgID,
+		CreationDate:      layout.CreationDate,
+		OriginalMsgID:     layout.OriginalMsgID,
+		OriginalMsgNameID: layout.OriginalMsgNameID,
+		GroupStatus:       layout.GroupStatus,
+		GroupReasons:      layout.GroupReasons,
+	}
+	for _, p := range layout.PaymentInfos {
+		pmtInf := PaymentInfoStatus{
+			OriginalPaymentInfoID: p.OriginalPaymentInfoID,
+			Status:                p.Status,
+			Reasons:               p.Reasons,
+		}
+		for _, t := range p.Transactions {
+			pmtInf.Transactions = append(pmtInf.Transactions, TransactionStatus(t))
+		}
+		report.PaymentInfos = append(report.PaymentInfos, pmtInf)
+	}
+	return report, nil
+}
+
+// StatusOf returns the status of a transaction : its own status if the report lists it,
+// else the status of its payment information group or, when the group has none, of the whole message unless that one is partial.
+// It returns nil if the report says nothing about the transaction.
+func (r *PaymentStatusReport) StatusOf(paymentInfoID string, endToEndID string) *TransactionStatus {
+	for _, p := range r.PaymentInfos {
+		if p.OriginalPaymentInfoID != paymentInfoID {
+			continue
+		}
+		for _, t := range p.Transactions {
+			if t.OriginalEndToEndID == endToEndID {
+				t := t
+				return &t
+			}
+		}
+		if p.Status == StatusPartiallyAccepted {
+			return nil
+		}
+		if p.Status != "" {
+			return &TransactionStatus{OriginalEndToEndID: endToEndID, Status: p.Status, Reasons: p.Reasons}
+		}
+		break
+	}
+	if r.GroupStatus != "" && r.GroupStatus != StatusPartiallyAccepted {
+		return &TransactionStatus{OriginalEndToEndID: endToEndID, Status: r.GroupStatus, Reasons: r.GroupReasons}
+	}
+	return nil
+}
+
+// checkApplied returns an error if the report is not about msgID or refers to a transaction not in known,
+// known being keyed by payment info ID then end to end ID
+func (r *PaymentStatusReport) checkApplied(msgID string, known map[string]map[string]bool) error {
+	if r.OriginalMsgID != msgID {
+		return errors.New("status report is about message " + r.OriginalMsgID + ", not " + msgID)
+	}
+	for _, p := range r.PaymentInfos {
+		transactions, ok := known[p.OriginalPaymentInfoID]
+		if !ok {
+			return errors.New("status report refers to unknown payment info ID " + p.OriginalPaymentInfoID)
+		}
+		for _, t := range p.Transactions {
+			if !transactions[t.OriginalEndToEndID] {
+				return errors.New("status report refers to unknown transaction " + t.OriginalEndToEndID)
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyStatusReport sets the TransactStatus of every transaction the report gives a status for.
+// It fails without changing the document if the report answers another message or refers to unknown transactions.
+func (doc *CreditTransfer) ApplyStatusReport(r *PaymentStatusReport) error {
+	known := map[string]map[string]bool{}
+	for _, p := range doc.PaymentInfos {
+		known[p.PaymentInfoID] = map[string]bool{}
+		for _, t := range p.PaymentTransactions {
+			known[p.PaymentInfoID][t.TransactIDe2e] = true
+		}
+	}
+	if err := r.checkApplied(doc.GroupHeaderMsgID, known); err != nil {
+		return err
+	}
+	for i := range doc.PaymentInfos {
+		p := &doc.PaymentInfos[i]
+		for j := range p.PaymentTransactions {
+			t := &p.PaymentTransactions[j]
+			t.TransactStatus = r.StatusOf(p.PaymentInfoID, t.TransactIDe2e)
+		}
+	}
+	return nil
+}
+
+// ApplyStatusReport sets the TransactStatus of every transaction the report gives a status for.
+// It fails without changing the document if the report answers another message or refers to unknown transactions.
+func (doc *DirectDebit) ApplyStatusReport(r *PaymentStatusReport) error {
+	known := map[string]map[string]bool{}
+	for _, p := range doc.PaymentInfos {
+		known[p.PaymentInfoID] = map[string]bool{}
+		for _, t := range p.PaymentTransactions {
+			known[p.PaymentInfoID][t.TransactIDe2e] = true
+		}
+	}
+	if err := r.checkApplied(doc.GroupHeaderMsgID, known); err != nil {
+		return err
+	}
+	for i := range doc.PaymentInfos {
+		p := &doc.PaymentInfos[i]
+		for j := range p.PaymentTransactions {
+			t := &p.PaymentTransactions[j]
+			t.TransactStatus = r.StatusOf(p.PaymentInfoID, t.TransactIDe2e)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package sepa
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPaymentStatusReport(t *testing.T) {
+	var report = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.002.001.03">
+  <CstmrPmtStsRpt>
+    <GrpHdr><MsgId>STS-1</MsgId><CreDtTm>2017-05-02T08:00:00</CreDtTm></GrpHdr>
+    <OrgnlGrpInfAndSts><OrgnlMsgId>VIR201705</OrgnlMsgId><OrgnlMsgNmId>pain.001.001.03</OrgnlMsgNmId><GrpSts>PART</GrpSts></OrgnlGrpInfAndSts>
+    <OrgnlPmtInfAndSts>
+      <OrgnlPmtInfId>PMT-1</OrgnlPmtInfId><PmtInfSts>PART</PmtInfSts>
+      <TxInfAndSts>
+        <StsId>STS-1-1</StsId><OrgnlEndToEndId>F2</OrgnlEndToEndId><TxSts>RJCT</TxSts>
+        <StsRsnInf><Rsn><Cd>AC04</Cd></Rsn><AddtlInf>Account closed</AddtlInf></StsRsnInf>
+      </TxInfAndSts>
+    </OrgnlPmtInfAndSts>
+    <OrgnlPmtInfAndSts>
+      <OrgnlPmtInfId>PMT-2</OrgnlPmtInfId><PmtInfSts>RJCT</PmtInfSts>
+      <StsRsnInf><Rsn><Cd>AM05</Cd></Rsn></StsRsnInf>
+    </OrgnlPmtInfAndSts>
+  </CstmrPmtStsRpt>
+</Document>`
+	r, err := ParsePaymentStatusReport(strings.NewReader(report))
+	if err != nil {
+		t.Fatal("Expected ParsePaymentStatusReport return nil", "got", err)
+	}
+	if r.Version != Pain002V03 || r.OriginalMsgID != "VIR201705" || r.GroupStatus != StatusPartiallyAccepted || len(r.PaymentInfos) != 2 {
+		t.Fatal("Expected partial pain.002.001.03 report with 2 payment infos", "got", r)
+	}
+	if tx := r.PaymentInfos[0].Transactions[0]; tx.Status != StatusRejected || tx.Reasons[0].Code != "AC04" || tx.Reasons[0].AdditionalInfo[0] != "Account closed" {
+		t.Error("Expected F2 rejected for AC04", "got", tx)
+	}
+
+	var sepaDoc = &CreditTransfer{}
+	if err := sepaDoc.InitDoc("VIR201705", "PMT-1", "2017-05-01T22:45:03", "2017-05-03", "Franz Holzapfel GMBH", "FR1420041010050500013M02606", "BKAUATWW", "DE", "some street", "some city"); err != nil {
+		t.Fatal("Expected InitDoc return nil", "got", err)
+	}
+	if err := sepaDoc.AddTransaction("F1", eur("10"), "EUR", "DEF Electronics", "GB29NWBK60161331926819", "BKAUATWW", "Cables"); err != nil {
+		t.Error("Expected AddTransaction return nil", "got", err)
+	}
+	if err := sepaDoc.AddTransaction("F2", eur("20"), "EUR", "D1F Electronics", "BE62510007547061", "BKAUATWW", "Monitor"); err != nil {
+		t.Error("Expected AddTransaction return nil", "got", err)
+	}
+	if err := sepaDoc.AddPaymentInfo("PMT-2", "2017-05-04", "Franz Holzapfel GMBH", "AT611904300234573201", "BKAUATWW", "DE", "some street", "some city"); err != nil {
+		t.Fatal("Expected AddPaymentInfo return nil", "got", err)
+	}
+	if err := sepaDoc.AddTransaction("F3", eur("30"), "EUR", "D2F Electronics", "EE382200221020145685", "BKAUATWW", "Notebooks"); err != nil {
+		t.Error("Expected AddTransaction return nil", "got", err)
+	}
+
+	if err := sepaDoc.ApplyStatusReport(r); err != nil {
+		t.Fatal("Expected ApplyStatusReport return nil", "got", err)
+	}
+	if s := sepaDoc.PaymentInfos[0].PaymentTransactions[0].TransactStatus; s != nil {
+		t.Error("Expected no status for F1", "got", s)
+	}
+	if s := sepaDoc.PaymentInfos[0].PaymentTransactions[1].TransactStatus; !s.Rejected() || s.Reasons[0].Code != "AC04" {
+		t.Error("Expected F2 rejected for AC04", "got", s)
+	}
+	if s := sepaDoc.PaymentInfos[1].PaymentTransactions[0].TransactStatus; !s.Rejected() || s.Reasons[0].Code != "AM05" {
+		t.Error("Expected F3 rejected with its payment info for AM05", "got", s)
+	}
+
+	sepaDoc.GroupHeaderMsgID = "OTHER"
+	if err := sepaDoc.ApplyStatusReport(r); err == nil {
+		t.Error("Expected ApplyStatusReport return an error for a report about another message")
+	}
+
+	var accepted = `<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.002.001.10"><CstmrPmtStsRpt><GrpHdr><MsgId>STS-2</MsgId></GrpHdr><OrgnlGrpInfAndSts><OrgnlMsgId>MSGID</OrgnlMsgId><GrpSts>ACCP</GrpSts></OrgnlGrpInfAndSts></CstmrPmtStsRpt></Document>`
+	r, err = ParsePaymentStatusReport(strings.NewReader(accepted))
+	if err != nil {
+		t.Fatal("Expected ParsePaymentStatusReport return nil", "got", err)
+	}
+	var ddDoc = &DirectDebit{}
+	if err := ddDoc.InitDoc("MSGID", "PMT", "2017-06-07T14:39:33", "2017-06-11", "Emitter Name", "FR1420041010050500013M02606", "BKAUATWW", "DE98ZZZ09999999999", "DE", "some street", "some city"); err != nil {
+		t.Fatal("Expected InitDoc return nil", "got", err)
+	}
+	if err := ddDoc.AddTransaction("E2E-1", eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "BFAUAUWA", "Invoice", "MNDT-1", "2017-01-01", SequenceFirst, ""); err != nil {
+		t.Error("Expected AddTransaction return nil", "got", err)
+	}
+	if err := ddDoc.ApplyStatusReport(r); err != nil {
+		t.Fatal("Expected ApplyStatusReport return nil", "got", err)
+	}
+	if s := ddDoc.PaymentInfos[0].PaymentTransactions[0].TransactStatus; s == nil || s.Status != StatusAccepted || s.Rejected() {
+		t.Error("Expected E2E-1 accepted with the group", "got", s)
+	}
+
+	var rejected = `<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.002.001.03"><CstmrPmtStsRpt><GrpHdr><MsgId>STS-3</MsgId></GrpHdr>` +
+		`<OrgnlGrpInfAndSts><OrgnlMsgId>MSGID</OrgnlMsgId><GrpSts>RJCT</GrpSts><StsRsnInf><Rsn><Cd>FF01</Cd></Rsn></StsRsnInf></OrgnlGrpInfAndSts>` +
+		`<OrgnlPmtInfAndSts><OrgnlPmtInfId>PMT</OrgnlPmtInfId><TxInfAndSts><OrgnlEndToEndId>E2E-2</OrgnlEndToEndId><TxSts>RJCT</TxSts></TxInfAndSts></OrgnlPmtInfAndSts>` +
+		`</CstmrPmtStsRpt></Document>`
+	r, err = ParsePaymentStatusReport(strings.NewReader(rejected))
+	if err != nil {
+		t.Fatal("Expected ParsePaymentStatusReport return nil", "got", err)
+	}
+	if s := r.StatusOf("PMT", "E2E-1"); s == nil || !s.Rejected() || s.Reasons[0].Code != "FF01" {
+		t.Error("Expected E2E-1 rejected with the group for FF01 when its payment info has no status", "got", s)
+	}
+}
@@ -0,0 +1,414 @@
+package sepa
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// streamEncoder writes a document piece by piece : the group header, the payment information groups without their
+// transactions, then the transactions one at a time. The transaction numbers and control sums come first in the
+// document, so they are declared beforehand and checked against the transactions written.
+type streamEncoder struct {
+	w   *bufio.Writer
+	enc *xml.Encoder
+	// prefix is the document up to the end of its group header, suffix its closing tags
+	prefix string
+	suffix string
+	// txName is the element of a transaction, CdtTrfTxInf or DrctDbtTxInf
+	txName string
+
+	transactNo, groupTransactNo int
+	ctrlSum, groupCtrlSum       Amount
+	written, groupWritten       int
+	writtenSum, groupWrittenSum Amount
+	// group is the ID of the open payment information group
+	group  string
+	open   bool
+	closed bool
+	err    error
+}
+
+// newStreamEncoder writes the start of a document serialized without groups, root is its message element
+func newStreamEncoder(w io.Writer, skeleton []byte, root string, txName string, transactNo int, ctrlSum Amount) (*streamEncoder, error) {
+	s := &streamEncoder{suffix: "</" + root + "></Document>", txName: txName, transactNo: transactNo, ctrlSum: ctrlSum}
+	if !strings.HasSuffix(string(skeleton), s.suffix) {
+		return nil, errors.New("unexpected end of document " + s.suffix)
+	}
+	s.prefix = strings.TrimSuffix(string(skeleton), s.suffix)
+	// the encoder shares the buffer of w, bufio.NewWriter returns it as it is
+	s.w = bufio.NewWriter(w)
+	s.enc = xml.NewEncoder(s.w)
+	if _, err := s.w.WriteString(s.prefix); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// startGroup ends the open group and writes the start of a group, skeleton being the document serialized with
+// this group alone and no transactions
+func (s *streamEncoder) startGroup(id string, skeleton []byte, transactNo int, ctrlSum Amount) error {
+	if err := s.endGroup(); err != nil {
+		return err
+	}
+	group := strings.TrimPrefix(string(skeleton), s.prefix)
+	if len(group) == len(skeleton) || !strings.HasSuffix(group, "</PmtInf>"+s.suffix) {
+		return s.fail(errors.New("unexpected layout of payment info " + id))
+	}
+	group = strings.TrimSuffix(group, "</PmtInf>"+s.suffix)
+	if _, err := s.w.WriteString(group); err != nil {
+		return s.fail(err)
+	}
+	s.group, s.open = id, true
+	s.groupTransactNo, s.groupCtrlSum = transactNo, ctrlSum
+	s.groupWritten, s.groupWrittenSum = 0, Amount{}
+	return nil
+}
+
+// encode writes a transaction of the open group in its layout v
+func (s *streamEncoder) encode(v interface{}, amount Amount) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+	if !s.open {
+		return errors.New("no payment info, call StartPaymentInfo first")
+	}
+	groupSum, err := s.groupWrittenSum.Add(amount)
+	if err != nil {
+		return err
+	}
+	sum, err := s.writtenSum.Add(amount)
+	if err != nil {
+		return err
+	}
+	if err := s.enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: s.txName}}); err != nil {
+		return s.fail(err)
+	}
+	s.groupWritten++
+	s.groupWrittenSum = groupSum
+	s.written++
+	s.writtenSum = sum
+	return nil
+}
+
+// endGroup checks the totals of the open group and writes its end
+func (s *streamEncoder) endGroup() error {
+	if err := s.check(); err != nil {
+		return err
+	}
+	if !s.open {
+		return nil
+	}
+	s.open = false
+	if err := checkTotals("payment info "+s.group, s.groupTransactNo, s.groupCtrlSum, "written", s.groupWritten, s.groupWrittenSum); err != nil {
+		return s.fail(err)
+	}
+	if _, err := s.w.WriteString("</PmtInf>"); err != nil {
+		return s.fail(err)
+	}
+	return nil
+}
+
+// close ends the open group and the document, checks the totals of the group header and flushes the output
+func (s *streamEncoder) close() error {
+	if err := s.endGroup(); err != nil {
+		return err
+	}
+	if err := checkTotals("group header", s.transactNo, s.ctrlSum, "written", s.written, s.writtenSum); err != nil {
+		return s.fail(err)
+	}
+	if _, err := s.w.WriteString(s.suffix); err != nil {
+		return s.fail(err)
+	}
+	s.closed = true
+	return s.w.Flush()
+}
+
+// check returns the error that stopped the encoder, once it failed or was closed the document can't be completed
+func (s *streamEncoder) check() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.closed {
+		return errors.New("encoder closed")
+	}
+	return nil
+}
+
+// fail stops the encoder with err
+func (s *streamEncoder) fail(err error) error {
+	s.err = err
+	return err
+}
+
+// checkTotals returns an error if the transactions written or read don't add up to the declared totals
+func checkTotals(what string, transactNo int, ctrlSum Amount, done string, n int, sum Amount) error {
+	if transactNo != n || ctrlSum.Cmp(sum) != 0 {
+		return errors.New(what + " declares " + strconv.Itoa(transactNo) + " transactions for " + ctrlSum.String() +
+			", " + strconv.Itoa(n) + " " + done + " for " + sum.String())
+	}
+	return nil
+}
+
+// CreditTransferEncoder writes a credit transfer to an io.Writer one transaction at a time, memory stays flat
+// however many transactions there are. The transaction numbers and control sums come first in the document : they
+// are taken from the group header and the groups as given, Close returns an error if the transactions written
+// don't add up to them. See StreamTransfers to have them computed.
+type CreditTransferEncoder struct {
+	s      *streamEncoder
+	header CreditTransfer
+}
+
+// NewCreditTransferEncoder writes the start of the document and its group header, the groups of header are ignored
+func NewCreditTransferEncoder(w io.Writer, header *CreditTransfer) (*CreditTransferEncoder, error) {
+	e := &CreditTransferEncoder{header: *header}
+	e.header.PaymentInfos = nil
+	skeleton, err := e.header.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	if e.s, err = newStreamEncoder(w, skeleton, "CstmrCdtTrfInitn", "CdtTrfTxInf", header.GroupHeaderTransactNo,
+		header.GroupHeaderCtrlSum); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// StartPaymentInfo ends the group being written and starts p, its transactions are ignored
+func (e *CreditTransferEncoder) StartPaymentInfo(p CreditPaymentInfo) error {
+	if err := e.s.check(); err != nil {
+		return err
+	}
+	p.PaymentTransactions = nil
+	skeleton := e.header
+	skeleton.PaymentInfos = []CreditPaymentInfo{p}
+	b, err := skeleton.Serialize()
+	if err != nil {
+		return err
+	}
+	return e.s.startGroup(p.PaymentInfoID, b, p.PaymentInfoTransactNo, p.PaymentInfoCtrlSum)
+}
+
+// Encode writes a transaction of the group being written
+func (e *CreditTransferEncoder) Encode(t CreditTransaction) error {
+	if e.header.Version() == Pain001V09 {
+		return e.s.encode(newCreditTransactionV09(t), t.TransactAmount.Amount)
+	}
+	return e.s.encode(t, t.TransactAmount.Amount)
+}
+
+// EncodeTransfer validates a transfer and writes it in the group being written
+func (e *CreditTransferEncoder) EncodeTransfer(t Transfer) error {
+	transaction, err := newCreditTransaction(t, e.header.text())
+	if err != nil {
+		return err
+	}
+	return e.Encode(transaction)
+}
+
+// Close ends the document and flushes it to the writer
+func (e *CreditTransferEncoder) Close() error {
+	return e.s.close()
+}
+
+// TransferSource calls yield for every transfer in turn and returns the first error it returns.
+// It yields the same transfers every time it is called.
+type TransferSource func(yield func(Transfer) error) error
+
+// StreamTransfers writes the document to w with the transfers of source added to its last payment information group,
+// as AddTransfer would, without keeping them in memory. source is walked twice : once to validate the transfers and
+// compute the totals, once to write them. The document itself is left unchanged.
+func (doc *CreditTransfer) StreamTransfers(w io.Writer, source TransferSource) error {
+	if len(doc.PaymentInfos) == 0 {
+		return errors.New("no payment info, call NewCreditTransfer or AddPaymentInfo first")
+	}
+	header := *doc
+	last := header.PaymentInfos[len(header.PaymentInfos)-1]
+	err := source(func(t Transfer) error {
+		transaction, err := newCreditTransaction(t, doc.text())
+		if err != nil {
+			return err
+		}
+		amount := transaction.TransactAmount.Amount
+		if last.PaymentInfoCtrlSum, err = last.PaymentInfoCtrlSum.Add(amount); err != nil {
+			return err
+		}
+		if header.GroupHeaderCtrlSum, err = header.GroupHeaderCtrlSum.Add(amount); err != nil {
+			return err
+		}
+		last.PaymentInfoTransactNo++
+		header.GroupHeaderTransactNo++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	enc, err := NewCreditTransferEncoder(w, &header)
+	if err != nil {
+		return err
+	}
+	for i, p := range doc.PaymentInfos {
+		if i == len(doc.PaymentInfos)-1 {
+			p = last
+		}
+		if err := enc.StartPaymentInfo(p); err != nil {
+			return err
+		}
+		for _, t := range p.PaymentTransactions {
+			if err := enc.Encode(t); err != nil {
+				return err
+			}
+		}
+	}
+	if err := source(enc.EncodeTransfer); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// DirectDebitEncoder writes a direct debit to an io.Writer one transaction at a time, memory stays flat however many
+// transactions there are. The transaction numbers and control sums come first in the document : they are taken from
+// the group header and the groups as given, Close returns an error if the transactions written don't add up to them.
+// See StreamCollections to have them computed.
+type DirectDebitEncoder struct {
+	s      *streamEncoder
+	header DirectDebit
+	group  DebitPaymentInfo
+}
+
+// NewDirectDebitEncoder writes the start of the document and its group header, the groups of header are ignored
+func NewDirectDebitEncoder(w io.Writer, header *DirectDebit) (*DirectDebitEncoder, error) {
+	e := &DirectDebitEncoder{header: *header}
+	e.header.PaymentInfos = nil
+	skeleton, err := e.header.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	if e.s, err = newStreamEncoder(w, skeleton, "CstmrDrctDbtInitn", "DrctDbtTxInf", header.GroupHeaderTransactNo,
+		header.GroupHeaderCtrlSum); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// StartPaymentInfo ends the group being written and starts p, its transactions are ignored
+func (e *DirectDebitEncoder) StartPaymentInfo(p DebitPaymentInfo) error {
+	if err := e.s.check(); err != nil {
+		return err
+	}
+	p.PaymentTransactions = nil
+	skeleton := e.header
+	skeleton.PaymentInfos = []DebitPaymentInfo{p}
+	b, err := skeleton.Serialize()
+	if err != nil {
+		return err
+	}
+	if err := e.s.startGroup(p.PaymentInfoID, b, p.PaymentInfoTransactNo, p.PaymentInfoCtrlSum); err != nil {
+		return err
+	}
+	e.group = p
+	return nil
+}
+
+// Encode writes a transaction of the group being written
+func (e *DirectDebitEncoder) Encode(t DebitTransaction) error {
+	if e.header.Version() == Pain008V08 {
+		return e.s.encode(newDebitTransactionV08(t), t.TransactAmount.Amount)
+	}
+	return e.s.encode(t, t.TransactAmount.Amount)
+}
+
+// EncodeCollection validates a direct debit and writes it in the group being written,
+// which must share its sequence type and collection date
+func (e *DirectDebitEncoder) EncodeCollection(d Collection) error {
+	d, err := e.header.checkCollection(d)
+	if err != nil {
+		return err
+	}
+	if d.SequenceType != e.group.PaymentTypeSequence || d.CollectionDate != e.group.PaymentExecDate {
+		return errors.New("collection " + d.ID + " is " + d.SequenceType + " on " + d.CollectionDate.String() +
+			", payment info " + e.group.PaymentInfoID + " is " + e.group.PaymentTypeSequence + " on " +
+			e.group.PaymentExecDate.String())
+	}
+	return e.Encode(newDebitTransaction(d))
+}
+
+// Close ends the document and flushes it to the writer
+func (e *DirectDebitEncoder) Close() error {
+	return e.s.close()
+}
+
+// CollectionSource calls yield for every direct debit in turn and returns the first error it returns.
+// It yields the same direct debits every time it is called.
+type CollectionSource func(yield func(Collection) error) error
+
+// StreamCollections writes the document to w with the direct debits of source added to the groups of their sequence
+// type and collection date, as AddCollection would, without keeping them in memory. source is walked once to
+// validate the direct debits and compute the totals, then once for every group it adds to.
+// The document itself is left unchanged.
+func (doc *DirectDebit) StreamCollections(w io.Writer, source CollectionSource) error {
+	plan := *doc
+	plan.PaymentInfos = append([]DebitPaymentInfo(nil), doc.PaymentInfos...)
+	added := map[string]bool{}
+	err := source(func(d Collection) error {
+		d, err := doc.checkCollection(d)
+		if err != nil {
+			return err
+		}
+		pmtInf := plan.paymentInfoFor(d.SequenceType, d.CollectionDate, doc.creditor.PaymentType)
+		if pmtInf.PaymentInfoCtrlSum, err = pmtInf.PaymentInfoCtrlSum.Add(d.Amount); err != nil {
+			return err
+		}
+		if plan.GroupHeaderCtrlSum, err = plan.GroupHeaderCtrlSum.Add(d.Amount); err != nil {
+			return err
+		}
+		pmtInf.PaymentInfoTransactNo++
+		plan.GroupHeaderTransactNo++
+		added[pmtInf.PaymentInfoID] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	enc, err := NewDirectDebitEncoder(w, &plan)
+	if err != nil {
+		return err
+	}
+	for i, p := range plan.PaymentInfos {
+		if err := enc.StartPaymentInfo(p); err != nil {
+			return err
+		}
+		for _, t := range p.PaymentTransactions {
+			if err := enc.Encode(t); err != nil {
+				return err
+			}
+		}
+		if !added[p.PaymentInfoID] {
+			continue
+		}
+		group := &plan.PaymentInfos[i]
+		err := source(func(d Collection) error {
+			checked, err := doc.checkCollection(d)
+			if err != nil {
+				return err
+			}
+			switch plan.findPaymentInfo(checked.SequenceType, checked.CollectionDate, doc.creditor.PaymentType) {
+			case group:
+			case nil:
+				return errors.New("collection " + d.ID + " missed by the first walk")
+			default:
+				return nil
+			}
+			return enc.EncodeCollection(d)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
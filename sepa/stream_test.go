@@ -0,0 +1,188 @@
+package sepa
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStreamTransfers(t *testing.T) {
+	for _, version := range []string{Pain001V03, Pain001V09} {
+		doc := splitTestTransfer(t, []string{"10", "20"}, []string{"30"})
+		if err := doc.SetVersion(version); err != nil {
+			t.Fatal("Expected SetVersion return nil", "got", err)
+		}
+		transfers := []Transfer{
+			{ID: "S1", Amount: eur("1.5"), Currency: "EUR", Creditor: Party{Name: "Creditor"},
+				CreditorAccount: Account{IBAN: "GB29NWBK60161331926819"}, Description: "Invoice S1"},
+			{ID: "S2", Amount: eur("2"), Currency: "EUR", Creditor: Party{Name: "Creditor"},
+				CreditorAccount: Account{IBAN: "GB29NWBK60161331926819"}, Description: "Invoice S2"},
+		}
+		walks := 0
+		source := func(yield func(Transfer) error) error {
+			walks++
+			for _, transfer := range transfers {
+				if err := yield(transfer); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		var buf bytes.Buffer
+		if err := doc.StreamTransfers(&buf, source); err != nil {
+			t.Fatal("Expected StreamTransfers return nil", "got", err)
+		}
+		if walks != 2 || doc.GroupHeaderTransactNo != 3 {
+			t.Error("Expected 2 walks and the document unchanged", "got", walks, doc.GroupHeaderTransactNo)
+		}
+
+		// the same document built in memory
+		for _, transfer := range transfers {
+			if err := doc.AddTransfer(transfer); err != nil {
+				t.Fatal("Expected AddTransfer return nil", "got", err)
+			}
+		}
+		expected, err := doc.Serialize()
+		if err != nil {
+			t.Fatal("Expected Serialize return nil", "got", err)
+		}
+		if buf.String() != string(expected) {
+			t.Error("Expected", string(expected), "got", buf.String())
+		}
+
+		var to bytes.Buffer
+		if err := doc.SerializeTo(&to); err != nil || to.String() != string(expected) {
+			t.Error("Expected SerializeTo write", string(expected), "got", to.String(), err)
+		}
+	}
+
+	doc := splitTestTransfer(t, []string{"10"}, nil)
+	bad := func(yield func(Transfer) error) error {
+		return yield(Transfer{ID: "BAD", Amount: eur("1"), Currency: "EUR", Creditor: Party{Name: "Creditor"},
+			CreditorAccount: Account{IBAN: "Creditor"}})
+	}
+	var buf bytes.Buffer
+	if err := doc.StreamTransfers(&buf, bad); err == nil || !strings.Contains(err.Error(), "BAD") || buf.Len() != 0 {
+		t.Error("Expected StreamTransfers return an error naming BAD before writing", "got", err, buf.Len())
+	}
+	failing := func(yield func(Transfer) error) error { return errors.New("source failed") }
+	if err := doc.StreamTransfers(&buf, failing); err == nil {
+		t.Error("Expected StreamTransfers return the error of the source")
+	}
+}
+func TestStreamCollections(t *testing.T) {
+	for _, version := range []string{Pain008V02, Pain008V08} {
+		doc, err := NewDirectDebit(DirectDebitConfig{Version: version, MsgID: "MSGID", PaymentInfoID: "PMT",
+			CreationDate: at("2017-06-07T14:39:33"), CollectionDate: day("2017-06-11"), Initiator: testEmitter,
+			CreditorAccount: testEmitterAccount, CreditorSchemeID: "DE98ZZZ09999999999"})
+		if err != nil {
+			t.Fatal("Expected NewDirectDebit return nil", "got", err)
+		}
+		if err := doc.AddTransaction("E2E-0", eur("5"), "EUR", "Debtor", "GB29NWBK60161331926819", "", "Invoice", "MNDT-0", "2017-01-01", SequenceRecurring, ""); err != nil {
+			t.Fatal("Expected AddTransaction return nil", "got", err)
+		}
+		var collections []Collection
+		for i, sequenceType := range []string{SequenceFirst, SequenceRecurring, SequenceFirst, SequenceFinal} {
+			id := "E2E-" + string(rune('1'+i))
+			collections = append(collections, Collection{ID: id, Amount: eur("10"), Currency: "EUR", Debtor: Party{Name: "Debtor"},
+				DebtorAccount: Account{IBAN: "GB29NWBK60161331926819"}, Description: "Invoice", MandateID: "MNDT-" + id,
+				MandateSignatureDate: day("2017-01-01"), SequenceType: sequenceType})
+		}
+		walks := 0
+		source := func(yield func(Collection) error) error {
+			walks++
+			for _, d := range collections {
+				if err := yield(d); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		var buf bytes.Buffer
+		if err := doc.StreamCollections(&buf, source); err != nil {
+			t.Fatal("Expected StreamCollections return nil", "got", err)
+		}
+		// once for the totals, once for each of the RCUR, FRST and FNAL groups
+		if walks != 4 || len(doc.PaymentInfos) != 1 {
+			t.Error("Expected 4 walks and the document unchanged", "got", walks, len(doc.PaymentInfos))
+		}
+
+		for _, d := range collections {
+			if err := doc.AddCollection(d); err != nil {
+				t.Fatal("Expected AddCollection return nil", "got", err)
+			}
+		}
+		expected, err := doc.Serialize()
+		if err != nil {
+			t.Fatal("Expected Serialize return nil", "got", err)
+		}
+		if buf.String() != string(expected) {
+			t.Error("Expected", string(expected), "got", buf.String())
+		}
+	}
+}
+func TestDirectDebitEncoder(t *testing.T) {
+	header, err := NewDirectDebit(DirectDebitConfig{MsgID: "MSGID", PaymentInfoID: "PMT", CreationDate: at("2017-06-07T14:39:33"),
+		CollectionDate: day("2017-06-11"), Initiator: testEmitter, CreditorAccount: testEmitterAccount, CreditorSchemeID: "DE98ZZZ09999999999"})
+	if err != nil {
+		t.Fatal("Expected NewDirectDebit return nil", "got", err)
+	}
+	group := header.paymentInfoFor(SequenceRecurring, day("2017-06-11"), header.creditor.PaymentType)
+	group.PaymentInfoTransactNo, group.PaymentInfoCtrlSum = 2, eur("20")
+	header.GroupHeaderTransactNo, header.GroupHeaderCtrlSum = 2, eur("20")
+
+	// fed from a channel
+	collections := make(chan Collection)
+	go func() {
+		for _, id := range []string{"E2E-1", "E2E-2"} {
+			collections <- Collection{ID: id, Amount: eur("10"), Currency: "EUR", Debtor: Party{Name: "Debtor"},
+				DebtorAccount: Account{IBAN: "GB29NWBK60161331926819"}, MandateID: "MNDT", MandateSignatureDate: day("2017-01-01"),
+				SequenceType: SequenceRecurring}
+		}
+		close(collections)
+	}()
+	var buf bytes.Buffer
+	enc, err := NewDirectDebitEncoder(&buf, header)
+	if err != nil {
+		t.Fatal("Expected NewDirectDebitEncoder return nil", "got", err)
+	}
+	if err := enc.EncodeCollection(Collection{}); err == nil {
+		t.Error("Expected EncodeCollection return an error before StartPaymentInfo")
+	}
+	if err := enc.StartPaymentInfo(header.PaymentInfos[0]); err != nil {
+		t.Fatal("Expected StartPaymentInfo return nil", "got", err)
+	}
+	for d := range collections {
+		if err := enc.EncodeCollection(d); err != nil {
+			t.Fatal("Expected EncodeCollection return nil", "got", err)
+		}
+	}
+	if err := enc.EncodeCollection(Collection{ID: "E2E-3", Amount: eur("10"), Currency: "EUR", Debtor: Party{Name: "Debtor"},
+		DebtorAccount: Account{IBAN: "GB29NWBK60161331926819"}, MandateID: "MNDT", MandateSignatureDate: day("2017-01-01"),
+		SequenceType: SequenceFirst}); err == nil {
+		t.Error("Expected EncodeCollection return an error for a FRST direct debit in a RCUR group")
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal("Expected Close return nil", "got", err)
+	}
+	if n := strings.Count(buf.String(), "<DrctDbtTxInf>"); n != 2 || !strings.HasSuffix(buf.String(), "</PmtInf></CstmrDrctDbtInitn></Document>") {
+		t.Error("Expected a complete document with 2 transactions", "got", buf.String())
+	}
+	if err := enc.Close(); err == nil {
+		t.Error("Expected Close return an error once closed")
+	}
+
+	// declared totals not met
+	buf.Reset()
+	header.GroupHeaderTransactNo, header.GroupHeaderCtrlSum = 3, eur("30")
+	if enc, err = NewDirectDebitEncoder(&buf, header); err != nil {
+		t.Fatal("Expected NewDirectDebitEncoder return nil", "got", err)
+	}
+	if err := enc.StartPaymentInfo(header.PaymentInfos[0]); err != nil {
+		t.Fatal("Expected StartPaymentInfo return nil", "got", err)
+	}
+	if err := enc.Close(); err == nil || !strings.Contains(err.Error(), "payment info PMT declares 2 transactions for 20.00, 0 written") {
+		t.Error("Expected Close return an error for the missing transactions", "got", err)
+	}
+}
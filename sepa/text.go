@@ -0,0 +1,106 @@
+package sepa
+
+import (
+	"errors"
+	"strconv"
+	"unicode/utf8"
+)
+
+// lengths of the ISO 20022 text types, counted in characters
+const (
+	max16Text  = 16
+	max35Text  = 35
+	max70Text  = 70
+	max140Text = 140
+)
+
+// maxAddressLines is the number of AdrLine the EPC guidelines allow, the schemas allow 7
+const maxAddressLines = 2
+
+// textRules are the character set and the lengths the text fields added to a document are checked against
+type textRules struct {
+	charset  Charset
+	truncate bool
+	// nameLength is the length of Nm : Max70Text before pain.001.001.09 and pain.008.001.08, Max140Text from them on
+	nameLength int
+}
+
+func newTextRules(version string, charset Charset, truncate bool) textRules {
+	r := textRules{charset: charset, truncate: truncate, nameLength: max70Text}
+	if version == Pain001V09 || version == Pain008V08 {
+		r.nameLength = max140Text
+	}
+	return r
+}
+
+// text returns a text field in the character set, cut to max characters when truncate is set
+func (r textRules) text(field string, value string, max int) (string, error) {
+	value, err := r.charset.sanitize(field, value)
+	if err != nil {
+		return value, err
+	}
+	if utf8.RuneCountInString(value) <= max {
+		return value, nil
+	}
+	if !r.truncate {
+		return value, errors.New(field + " is longer than " + strconv.Itoa(max) + " characters")
+	}
+	return truncateText(value, max), nil
+}
+
+// name returns the name of a party in the character set, cut to the length of Nm when truncate is set
+func (r textRules) name(name string) (string, error) {
+	return r.text("name", name, r.nameLength)
+}
+
+// party returns the party with its name and postal address in the character set and within their lengths
+func (r textRules) party(p Party) (Party, error) {
+	var err error
+	if p.Name, err = r.name(p.Name); err != nil {
+		return p, err
+	}
+	a := &p.Address
+	for _, f := range []struct {
+		name  string
+		value *string
+		max   int
+	}{{"street name", &a.StreetName, max70Text}, {"building number", &a.BuildingNumber, max16Text},
+		{"post code", &a.PostCode, max16Text}, {"town name", &a.TownName, max35Text}} {
+		if *f.value, err = r.text(f.name, *f.value, f.max); err != nil {
+			return p, err
+		}
+	}
+	if len(a.AddressLines) > maxAddressLines {
+		return p, errors.New(strconv.Itoa(len(a.AddressLines)) + " address lines, at most " + strconv.Itoa(maxAddressLines))
+	}
+	lines := make([]string, len(a.AddressLines))
+	for i, line := range a.AddressLines {
+		if lines[i], err = r.text("address line", line, max70Text); err != nil {
+			return p, err
+		}
+	}
+	if a.AddressLines != nil {
+		a.AddressLines = lines
+	}
+	return p, nil
+}
+
+// checkID returns an error if an identifier is longer than Max35Text, identifiers are never cut
+func checkID(field string, id string) error {
+	if utf8.RuneCountInString(id) > max35Text {
+		return errors.New(field + " " + id + " is longer than " + strconv.Itoa(max35Text) + " characters")
+	}
+	return nil
+}
+
+// truncateText returns the first max characters of a text, never cutting inside a multi-byte character
+func truncateText(text string, max int) string {
+	n := 0
+	for i := range text {
+		if n == max {
+			return text[:i]
+		}
+		n++
+	}
+	return text
+}
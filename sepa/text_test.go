@@ -0,0 +1,130 @@
+package sepa
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTruncateText(t *testing.T) {
+	for _, c := range []struct {
+		text     string
+		max      int
+		expected string
+	}{
+		{"Invoice", 3, "Inv"},
+		{"Müßig", 3, "Müß"},
+		{"Łódź", 4, "Łódź"},
+		{"日本語", 2, "日本"},
+		{"", 2, ""},
+	} {
+		if got := truncateText(c.text, c.max); got != c.expected {
+			t.Error("Expected", c.expected, "got", got)
+		}
+	}
+}
+func TestTextLengthsCreditTransfer(t *testing.T) {
+	long := strings.Repeat("ä", 71)
+	config := CreditTransferConfig{MsgID: "MSGID", CreationDate: at("2017-05-01T22:45:03"),
+		ExecutionDate: day("2017-05-03"), Initiator: testEmitter, DebtorAccount: testEmitterAccount}
+	c := config
+	c.MsgID = strings.Repeat("M", 36)
+	if _, err := NewCreditTransfer(c); err == nil || err.Error() != "message ID "+c.MsgID+" is longer than 35 characters" {
+		t.Error("Expected NewCreditTransfer return an error for the message ID", "got", err)
+	}
+	c = config
+	c.Initiator = Party{Name: long}
+	if _, err := NewCreditTransfer(c); err == nil || err.Error() != "initiator : name is longer than 70 characters" {
+		t.Error("Expected NewCreditTransfer return an error for the initiator name", "got", err)
+	}
+	c.Version = Pain001V09
+	if _, err := NewCreditTransfer(c); err != nil {
+		t.Error("Expected NewCreditTransfer accept a name of 71 characters in pain.001.001.09", "got", err)
+	}
+	doc := &CreditTransfer{}
+	if err := doc.InitDoc("MSGID", "PMT", "2017-05-01T22:45:03", "2017-05-03", long, "FR1420041010050500013M02606", "", "DE", "street", "city"); err == nil {
+		t.Error("Expected InitDoc return an error for the emitter name")
+	}
+
+	doc, err := NewCreditTransfer(config)
+	if err != nil {
+		t.Fatal("Expected NewCreditTransfer return nil", "got", err)
+	}
+	for _, c := range []struct {
+		transfer Transfer
+		expected string
+	}{
+		{Transfer{ID: strings.Repeat("E", 36), Creditor: Party{Name: "Creditor"}},
+			"transaction " + strings.Repeat("E", 36) + " : end to end ID " + strings.Repeat("E", 36) + " is longer than 35 characters"},
+		{Transfer{ID: "F1", Creditor: Party{Name: long}}, "transaction F1 : creditor : name is longer than 70 characters"},
+		{Transfer{ID: "F1", Creditor: Party{Name: "Creditor"}, Description: strings.Repeat("x", 141)},
+			"transaction F1 : description is longer than 140 characters"},
+		{Transfer{ID: "F1", Creditor: Party{Name: "Creditor", Address: PostalAddress{AddressLines: []string{"1", "2", "3"}}}},
+			"transaction F1 : creditor : 3 address lines, at most 2"},
+		{Transfer{ID: "F1", Creditor: Party{Name: "Creditor", Address: PostalAddress{AddressLines: []string{long}}}},
+			"transaction F1 : creditor : address line is longer than 70 characters"},
+		{Transfer{ID: "F1", Creditor: Party{Name: "Creditor", Address: PostalAddress{TownName: strings.Repeat("x", 36)}}},
+			"transaction F1 : creditor : town name is longer than 35 characters"},
+	} {
+		c.transfer.Amount, c.transfer.Currency = eur("10"), "EUR"
+		c.transfer.CreditorAccount = Account{IBAN: "GB29NWBK60161331926819"}
+		if err := doc.AddTransfer(c.transfer); err == nil || err.Error() != c.expected {
+			t.Error("Expected", c.expected, "got", err)
+		}
+	}
+	if doc.GroupHeaderTransactNo != 0 {
+		t.Error("Expected no transaction added", "got", doc.GroupHeaderTransactNo)
+	}
+
+	// truncated at a character boundary
+	doc.SetTruncate(true)
+	err = doc.AddTransfer(Transfer{ID: "F1", Amount: eur("10"), Currency: "EUR", CreditorAccount: Account{IBAN: "GB29NWBK60161331926819"},
+		Creditor: Party{Name: long, Address: PostalAddress{Country: "DE", AddressLines: []string{long}}}, Description: strings.Repeat("ß", 150)})
+	if err != nil {
+		t.Fatal("Expected AddTransfer return nil", "got", err)
+	}
+	tx := doc.PaymentInfos[0].PaymentTransactions[0]
+	if tx.TransactCreditorName != long[:140] || tx.TransactCreditorPostalAddress.AddressLines[0] != long[:140] ||
+		tx.TransactMotif.Unstructured != strings.Repeat("ß", 140) {
+		t.Error("Expected the text fields cut to 70 and 140 characters", "got", tx)
+	}
+	if err := doc.AddTransaction(strings.Repeat("E", 36), eur("10"), "EUR", "Creditor", "GB29NWBK60161331926819", "", ""); err == nil {
+		t.Error("Expected AddTransaction never truncate the end to end ID")
+	}
+	if err := doc.Validate(); err != nil {
+		t.Error("Expected Validate return nil", "got", err)
+	}
+}
+func TestTextLengthsDirectDebit(t *testing.T) {
+	config := DirectDebitConfig{MsgID: "MSGID", CreationDate: at("2017-06-07T14:39:33"), CollectionDate: day("2017-06-11"),
+		Initiator: testEmitter, CreditorAccount: testEmitterAccount, CreditorSchemeID: "DE98ZZZ09999999999"}
+	c := config
+	c.CreditorSchemeID = strings.Repeat("D", 36)
+	if _, err := NewDirectDebit(c); err == nil || !strings.HasPrefix(err.Error(), "creditor scheme ID") {
+		t.Error("Expected NewDirectDebit return an error for the creditor scheme ID", "got", err)
+	}
+	doc := &DirectDebit{}
+	if err := doc.InitDoc("MSGID", strings.Repeat("P", 36), "2017-06-07T14:39:33", "2017-06-11", "Emitter", "FR1420041010050500013M02606", "",
+		"DE98ZZZ09999999999", "DE", "street", "city"); err == nil || !strings.HasPrefix(err.Error(), "payment info ID") {
+		t.Error("Expected InitDoc return an error for the payment info ID", "got", err)
+	}
+
+	doc, err := NewDirectDebit(config)
+	if err != nil {
+		t.Fatal("Expected NewDirectDebit return nil", "got", err)
+	}
+	err = doc.AddTransaction("E2E", eur("10"), "EUR", "Debtor", "GB29NWBK60161331926819", "", "Invoice", strings.Repeat("M", 36), "2017-01-01", SequenceFirst, "")
+	if err == nil || err.Error() != "transaction E2E : mandate ID "+strings.Repeat("M", 36)+" is longer than 35 characters" {
+		t.Error("Expected AddTransaction return an error for the mandate ID", "got", err)
+	}
+	err = doc.AddTransaction("E2E", eur("10"), "EUR", strings.Repeat("D", 71), "GB29NWBK60161331926819", "", "Invoice", "MNDT", "2017-01-01", SequenceFirst, "")
+	if err == nil || err.Error() != "transaction E2E : debtor : name is longer than 70 characters" {
+		t.Error("Expected AddTransaction return an error for the debtor name", "got", err)
+	}
+	if err := doc.SetVersion(Pain008V08); err != nil {
+		t.Fatal("Expected SetVersion return nil", "got", err)
+	}
+	err = doc.AddTransaction("E2E", eur("10"), "EUR", strings.Repeat("D", 71), "GB29NWBK60161331926819", "", "Invoice", "MNDT", "2017-01-01", SequenceFirst, "")
+	if err != nil {
+		t.Error("Expected AddTransaction accept a name of 71 characters in pain.008.001.08", "got", err)
+	}
+}
@@ -0,0 +1,58 @@
+package sepa
+
+import (
+	"errors"
+	"strings"
+)
+
+// Schema versions of the generated documents
+const (
+	Pain001V03 = "pain.001.001.03" // Customer Credit Transfer Initiation V03
+	Pain001V09 = "pain.001.001.09" // Customer Credit Transfer Initiation V09, SEPA 2019 rulebook
+
+	Pain008DKV02 = "pain.008.003.02" // Customer Direct Debit Initiation V02, German DK variant
+	Pain008V02   = "pain.008.001.02" // Customer Direct Debit Initiation V02
+	Pain008V08   = "pain.008.001.08" // Customer Direct Debit Initiation V08, SEPA 2023 rulebook
+
+	Pain002DKV03 = "pain.002.003.03" // Customer Payment Status Report V03, German DK variant
+	Pain002V03   = "pain.002.001.03" // Customer Payment Status Report V03
+	Pain002V10   = "pain.002.001.10" // Customer Payment Status Report V10, SEPA 2019 rulebook
+
+	Camt053V02 = "camt.053.001.02" // Bank To Customer Statement V02
+	Camt053V04 = "camt.053.001.04" // Bank To Customer Statement V04
+	Camt053V08 = "camt.053.001.08" // Bank To Customer Statement V08
+
+	Camt054V02 = "camt.054.001.02" // Bank To Customer Debit Credit Notification V02
+	Camt054V04 = "camt.054.001.04" // Bank To Customer Debit Credit Notification V04
+	Camt054V08 = "camt.054.001.08" // Bank To Customer Debit Credit Notification V08
+)
+
+const (
+	namespacePrefix = "urn:iso:std:iso:20022:tech:xsd:"
+	xsiNamespace    = "http://www.w3.org/2001/XMLSchema-instance"
+)
+
+// namespace returns the target namespace of a schema version
+func namespace(version string) string {
+	return namespacePrefix + version
+}
+
+// schemaLocation returns the xsi:schemaLocation of a schema version
+func schemaLocation(version string) string {
+	return namespace(version) + " " + version + ".xsd"
+}
+
+// versionOf returns the schema version of a namespace
+func versionOf(ns string) string {
+	return strings.TrimPrefix(ns, namespacePrefix)
+}
+
+// checkVersion returns an error if version is not one of supported
+func checkVersion(version string, supported ...string) error {
+	for _, v := range supported {
+		if v == version {
+			return nil
+		}
+	}
+	return errors.New("unsupported schema version " + version)
+}
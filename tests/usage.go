@@ -3,21 +3,36 @@ package main
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/flofuenf/gosepa/sepa"
 )
 
 func main() {
+	emitter := sepa.Party{
+		Name:    "Emitter Name",
+		Address: sepa.PostalAddress{Country: "US", AddressLines: []string{"Your Street 120", "76657 Your City, Country"}},
+	}
+	emitterAccount := sepa.Account{IBAN: "FR1420041010050500013M02606"}
+	emitterAgent := sepa.Agent{BIC: "BKAUATWW"}
+
 	// Direct Debit
-	ddXML := &sepa.DirectDebit{}
-	if err := ddXML.InitDoc("MSGID", "2017-06-07T14:39:33", "2017-06-07T14:39:33",
-		"2017-06-11", "Emitter Name", "FR1420041010050500013M02606", "BKAUATWW",
-		"emitterID", "US", "Your Street 120", "76657 Your City, Country"); err != nil {
+	ddXML, err := sepa.NewDirectDebit(sepa.DirectDebitConfig{
+		MsgID:            "MSGID",
+		PaymentInfoID:    "paymentInfoID",
+		CollectionDate:   sepa.Date{Year: 2017, Month: time.June, Day: 11},
+		Initiator:        emitter,
+		CreditorAccount:  emitterAccount,
+		CreditorAgent:    emitterAgent,
+		CreditorSchemeID: "DE98ZZZ09999999999",
+	})
+	if err != nil {
 		log.Fatal("can't create sepa direct debit document : ", err)
 	}
 
-	if err := ddXML.AddTransaction("F201705", 70000, "EUR", "DEV Electronics",
-		"GB29NWBK60161331926819", "BFAUAUWA", "Invoice 12345", "mandandtIT", "2017-06-07T14:39:33"); err != nil {
+	if err := ddXML.AddTransaction("F201705", sepa.AmountOf(7000000, "EUR"), "EUR", "DEV Electronics",
+		"GB29NWBK60161331926819", "BFAUAUWA", "Invoice 12345", "mandandtIT", "2017-06-07",
+		sepa.SequenceRecurring, "2017-06-11"); err != nil {
 		log.Fatal("can't add transaction in the sepa document : ", err)
 	}
 
@@ -29,19 +44,24 @@ func main() {
 	fmt.Println(string(res))
 
 	// Credit Transfer
-	ctXML := &sepa.CreditTransfer{}
-	if err := ctXML.InitDoc("MSGID", "paymentInfoID", "2017-06-07T14:39:33",
-		"2017-06-11", "Emitter Name", "FR1420041010050500013M02606", "BKAUATWW",
-		"US", "Your Street 120", "76657 Your City, Country"); err != nil {
+	ctXML, err := sepa.NewCreditTransfer(sepa.CreditTransferConfig{
+		MsgID:         "MSGID",
+		PaymentInfoID: "paymentInfoID",
+		ExecutionDate: sepa.Date{Year: 2017, Month: time.June, Day: 11},
+		Initiator:     emitter,
+		DebtorAccount: emitterAccount,
+		DebtorAgent:   emitterAgent,
+	})
+	if err != nil {
 		log.Fatal("can't create sepa credit transfer document : ", err)
 	}
 
-	if err := ddXML.AddTransaction("F201705", 70000, "EUR", "DEV Electronics",
-		"GB29NWBK60161331926819", "BFAUAUWA", "Invoice 12345", "mandandtIT", "2017-06-07T14:39:33"); err != nil {
+	if err := ctXML.AddTransaction("F201705", sepa.AmountOf(7000000, "EUR"), "EUR", "DEV Electronics",
+		"GB29NWBK60161331926819", "BFAUAUWA", "Invoice 12345"); err != nil {
 		log.Fatal("can't add transaction in the sepa document : ", err)
 	}
 
-	res, err = ddXML.PrettySerialize()
+	res, err = ctXML.PrettySerialize()
 	if err != nil {
 		log.Fatal("can't get the xml doc : ", err)
 	}